@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/i18n"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/models"
+	"golang.org/x/text/message"
+)
+
+// AccessMode tells who may start using the bot.
+type AccessMode string
+
+const (
+	// AccessOpen lets anyone in.
+	AccessOpen AccessMode = "open"
+	// AccessInvite lets in those who start the bot with an invite code or a
+	// deck invite link.
+	AccessInvite AccessMode = "invite"
+	// AccessApproval lets in those an admin approves.
+	AccessApproval AccessMode = "approval"
+)
+
+func (app *App) isInviteCode(token string) bool {
+	return app.Config.AccessMode == AccessInvite && slices.Contains(app.Config.InviteCodes, token)
+}
+
+// checkAccess tells whether the update may be handled, registering newcomers
+// the way the access mode asks for. Admins are always let in. Newcomers
+// posting in a group are ignored rather than registered, they ask for access
+// in their private chat with the bot.
+func (app *App) checkAccess(ctx context.Context, update tgbotapi.Update) bool {
+	from := update.SentFrom()
+	if from == nil || app.isAdmin(from.ID) {
+		return true
+	}
+	inGroup := isGroupChat(update.FromChat())
+
+	user, err := app.DB.FindUserByTelegramID(from.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return false
+	}
+
+	if user != nil {
+		switch user.Status {
+		case models.UserStatusActive:
+			return true
+		case models.UserStatusInactive:
+			// Writing again means the user unblocked the bot, posting in a
+			// group doesn't tell
+			if inGroup {
+				return true
+			}
+			if err := app.DB.SetUserStatus(user.ID, models.UserStatusActive); err != nil {
+				slog.ErrorContext(ctx, "Error reactivating user", "user_id", user.ID, "error", err)
+				return false
+			}
+			return true
+		case models.UserStatusPending:
+			app.sendAccessReply(ctx, update, printer(user).Sprintf("Your request to use the bot is waiting for an admin's approval."))
+		}
+		return false
+	}
+
+	if inGroup && app.Config.AccessMode != AccessOpen {
+		return false
+	}
+
+	switch app.Config.AccessMode {
+	case AccessInvite:
+		return app.admitInvitedUser(ctx, update)
+	case AccessApproval:
+		app.requestApproval(ctx, update)
+		return false
+	default:
+		return true
+	}
+}
+
+// admitInvitedUser registers a newcomer who started the bot with an invite
+// code. Deck invite links let them in too and are left to the /start command.
+func (app *App) admitInvitedUser(ctx context.Context, update tgbotapi.Update) bool {
+	var token string
+	if message := update.Message; message != nil && message.Command() == "start" {
+		token = strings.TrimSpace(message.CommandArguments())
+	}
+
+	if token != "" && app.isInviteCode(token) {
+		if _, err := app.SaveUser(update.Message.From); err != nil {
+			slog.ErrorContext(ctx, "Error saving user", "error", err)
+			return false
+		}
+		app.sendAccessReply(ctx, update, newcomerPrinter(update).Sprintf("Welcome! Send a message with a #hashtag to save your first phrase."))
+		return false
+	}
+
+	if token != "" {
+		deck, err := app.DB.FindDeckByInviteToken(token)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error finding deck", "error", err)
+			return false
+		}
+		if deck != nil {
+			return true
+		}
+	}
+
+	app.sendAccessReply(ctx, update, newcomerPrinter(update).Sprintf("This bot is invite-only. Start it with /start <invite-code>."))
+	return false
+}
+
+// requestApproval registers a newcomer as pending and asks the admins to
+// approve them.
+func (app *App) requestApproval(ctx context.Context, update tgbotapi.Update) {
+	user, err := app.saveUserWithStatus(update.SentFrom(), models.UserStatusPending)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error saving user", "error", err)
+		return
+	}
+
+	app.sendAccessReply(ctx, update, printer(user).Sprintf("Your request to use the bot was sent to the admins. You'll get a message once it's approved."))
+
+	text := fmt.Sprintf("New user %s (#%d) asks to use the bot.", formatUserName(user), user.ID)
+	for _, adminID := range app.Config.AdminIDs {
+		message := tgbotapi.NewMessage(adminID, text)
+		message.ReplyMarkup = accessKeyboard(user.ID)
+		if _, err := app.TelegramBot.Send(message); err != nil {
+			slog.ErrorContext(ctx, "Notifying the admin failed", "admin_id", adminID, "error", err)
+		}
+	}
+}
+
+func accessKeyboard(userID uint) tgbotapi.InlineKeyboardMarkup {
+	id := strconv.Itoa(int(userID))
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Approve", "access:approve:"+id),
+			tgbotapi.NewInlineKeyboardButtonData("Reject", "access:reject:"+id),
+		),
+	)
+}
+
+// handleAccessCallback approves or rejects a pending user from the buttons
+// sent to the admins.
+func (app *App) handleAccessCallback(ctx context.Context, callbackQuery *tgbotapi.CallbackQuery, data []string) {
+	if len(data) != 3 || !app.isAdmin(callbackQuery.From.ID) {
+		slog.WarnContext(ctx, "Invalid callback data", "data", callbackQuery.Data)
+		return
+	}
+
+	user, err := app.findUserByReference(data[2])
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+
+	status := models.UserStatusActive
+	if data[1] == "reject" {
+		status = models.UserStatusRejected
+	}
+
+	reply := app.setUserAccess(ctx, user, status)
+
+	message := callbackQuery.Message
+	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, message.Text+"\n\n"+reply)
+	if _, err := app.TelegramBot.Send(edit); err != nil {
+		slog.ErrorContext(ctx, "Failed to update the access request", "error", err)
+	}
+
+	if _, err := app.TelegramBot.Request(tgbotapi.NewCallback(callbackQuery.ID, reply)); err != nil {
+		slog.ErrorContext(ctx, "Failed to send callback response", "error", err)
+	}
+}
+
+// setUserAccess approves or rejects the user, lets them know and returns the
+// outcome for the admin.
+func (app *App) setUserAccess(ctx context.Context, user *models.User, status models.UserStatus) string {
+	if err := app.DB.SetUserStatus(user.ID, status); err != nil {
+		slog.ErrorContext(ctx, "Error updating the user status", "user_id", user.ID, "error", err)
+		return "Updating the user failed."
+	}
+
+	p := printer(user)
+	notice, reply := p.Sprintf("Your request to use the bot was approved. Send a message with a #hashtag to save your first phrase."), "Approved "
+	if status == models.UserStatusRejected {
+		notice, reply = p.Sprintf("Your request to use the bot was declined."), "Rejected "
+	}
+
+	if err := app.SendText(user.TelegramChatID, notice); err != nil {
+		slog.ErrorContext(ctx, "Notifying the user failed", "user_id", user.ID, "error", err)
+	}
+
+	return reply + formatUserName(user) + "."
+}
+
+// newcomerPrinter returns the printer of the bot messages in the language of
+// the Telegram app of someone who isn't a user yet.
+func newcomerPrinter(update tgbotapi.Update) *message.Printer {
+	return i18n.Printer(update.SentFrom().LanguageCode)
+}
+
+// sendAccessReply answers newcomers in their private chat, groups aren't
+// bothered with them.
+func (app *App) sendAccessReply(ctx context.Context, update tgbotapi.Update, text string) {
+	message := update.Message
+	if message == nil || isGroupChat(message.Chat) {
+		return
+	}
+
+	if err := app.SendText(message.Chat.ID, text); err != nil {
+		slog.ErrorContext(ctx, "Sending the access reply failed", "error", err)
+	}
+}
+
+// DeactivateChat marks the user of a private chat the bot can't write to
+// anymore as inactive, so they stop getting phrases to review.
+func (app *App) DeactivateChat(chatID int64) {
+	ctx := logging.With(context.Background(), "chat_id", chatID)
+
+	user, err := app.DB.FindUserByTelegramID(chatID)
+	if err != nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+	if user == nil || user.Status != models.UserStatusActive {
+		return
+	}
+
+	if err := app.DB.SetUserStatus(user.ID, models.UserStatusInactive); err != nil {
+		slog.ErrorContext(ctx, "Error deactivating user", "user_id", user.ID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Deactivated user who blocked the bot", "user_id", user.ID)
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPrivateMessageUpdate(fromID int64, text string) tgbotapi.Update {
+	message := &tgbotapi.Message{
+		MessageID: 1000,
+		From:      &tgbotapi.User{ID: fromID, FirstName: "New"},
+		Chat:      &tgbotapi.Chat{ID: fromID, Type: "private"},
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		length := len(text)
+		if i := strings.IndexByte(text, ' '); i >= 0 {
+			length = i
+		}
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Length: length}}
+	}
+
+	return tgbotapi.Update{Message: message}
+}
+
+func TestCheckAccessOpen(t *testing.T) {
+	setup := setupTestReview(t)
+
+	assert.True(t, setup.app.checkAccess(context.Background(), newPrivateMessageUpdate(500, "Hallo #german")))
+}
+
+func TestCheckAccessInvite(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	setup.app.Config.AccessMode = AccessInvite
+	setup.app.Config.InviteCodes = []string{"letmein"}
+
+	ctx := context.Background()
+	assert.False(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(500, "Hallo #german")))
+	assert.False(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(500, "/start wrong")))
+
+	user, err := setup.app.DB.FindUserByTelegramID(500)
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+
+	// The invite code registers the user and is answered with a welcome
+	assert.False(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(500, "/start letmein")))
+	assert.True(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(500, "Hallo #german")))
+	assert.Len(t, telegram.sent("sendMessage"), 3)
+
+	// Existing users keep their access
+	assert.True(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(setup.user.TelegramChatID, "Tschüss #german")))
+}
+
+func TestCheckAccessApproval(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	setup.app.Config.AccessMode = AccessApproval
+	setup.app.Config.AdminIDs = []int64{900}
+
+	ctx := context.Background()
+	assert.True(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(900, "/admin users")))
+	assert.False(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(500, "Hallo #german")))
+
+	user, err := setup.app.DB.FindUserByTelegramID(500)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusPending, user.Status)
+	assert.False(t, user.CanReview())
+
+	// The user is told to wait and the admin gets the approval buttons
+	requests := telegram.sent("sendMessage")
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "900", requests[1].Params.Get("chat_id"))
+	assert.Contains(t, requests[1].Params.Get("reply_markup"), "access:approve:")
+
+	assert.False(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(500, "Hallo again #german")))
+
+	setup.app.setUserAccess(ctx, user, models.UserStatusActive)
+	assert.True(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(500, "Hallo #german")))
+}
+
+func TestCheckAccessIgnoresNewcomersInGroups(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	setup.app.Config.AccessMode = AccessApproval
+	setup.app.Config.AdminIDs = []int64{900}
+	ctx := context.Background()
+
+	groupUpdate := func(fromID int64, text string) tgbotapi.Update {
+		update := newPrivateMessageUpdate(fromID, text)
+		update.Message.Chat = &tgbotapi.Chat{ID: -100, Type: "group", Title: "Team"}
+		return update
+	}
+
+	// Group members neither become pending users nor bother the admins
+	assert.False(t, setup.app.checkAccess(ctx, groupUpdate(500, "Hallo #german")))
+
+	user, err := setup.app.DB.FindUserByTelegramID(500)
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+	assert.Empty(t, telegram.sent("sendMessage"))
+
+	// Users already let in keep posting in the group
+	assert.True(t, setup.app.checkAccess(ctx, groupUpdate(setup.user.TelegramChatID, "Tschüss #german")))
+
+	// Posting in a group doesn't bring back users who blocked the bot
+	assert.NoError(t, setup.app.DB.SetUserStatus(setup.user.ID, models.UserStatusInactive))
+	assert.True(t, setup.app.checkAccess(ctx, groupUpdate(setup.user.TelegramChatID, "Tschüss #german")))
+
+	user, err = setup.app.DB.FindUser(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusInactive, user.Status)
+}
+
+func TestDeactivateChat(t *testing.T) {
+	setup := setupTestReview(t)
+	ctx := context.Background()
+
+	setup.app.DeactivateChat(setup.user.TelegramChatID)
+
+	user, err := setup.app.DB.FindUser(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusInactive, user.Status)
+	assert.False(t, user.CanReview())
+
+	// Writing to the bot again brings the user back
+	assert.True(t, setup.app.checkAccess(ctx, newPrivateMessageUpdate(setup.user.TelegramChatID, "Hallo #german")))
+
+	user, err = setup.app.DB.FindUser(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusActive, user.Status)
+}
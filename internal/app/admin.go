@@ -0,0 +1,243 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+const (
+	adminUsage = "Usage:\n/admin users - list the users\n/admin stats - usage statistics\n/admin broadcast <text> - message all users\n/admin block <user> - ignore a user\n/admin unblock <user> - stop ignoring a user\n/admin approve <user> - let a pending user in\n/admin reject <user> - turn a pending user down\n\n<user> is a user ID or @username."
+
+	adminUsersLimit = 50
+)
+
+// isAdmin tells whether the Telegram user is one of the operators set in the
+// config.
+func (app *App) isAdmin(telegramID int64) bool {
+	return slices.Contains(app.Config.AdminIDs, telegramID)
+}
+
+func (app *App) handleAdminCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	if !app.isAdmin(message.From.ID) {
+		slog.WarnContext(ctx, "Admin command from a non-admin", "user_id", user.ID)
+		return
+	}
+
+	command, arguments, _ := strings.Cut(strings.TrimSpace(message.CommandArguments()), " ")
+	arguments = strings.TrimSpace(arguments)
+
+	switch strings.ToLower(command) {
+	case "users":
+		app.handleAdminUsers(ctx, message)
+	case "stats":
+		app.handleAdminStats(ctx, message)
+	case "broadcast":
+		app.handleAdminBroadcast(ctx, message, arguments)
+	case "block":
+		app.handleAdminBlock(ctx, message, arguments, true)
+	case "unblock":
+		app.handleAdminBlock(ctx, message, arguments, false)
+	case "approve":
+		app.handleAdminAccess(ctx, message, arguments, models.UserStatusActive)
+	case "reject":
+		app.handleAdminAccess(ctx, message, arguments, models.UserStatusRejected)
+	default:
+		app.sendAdminReply(ctx, message, adminUsage)
+	}
+}
+
+func (app *App) handleAdminUsers(ctx context.Context, message *tgbotapi.Message) {
+	users, err := app.DB.GetAllUsers()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error retrieving users", "error", err)
+		return
+	}
+
+	app.sendAdminReply(ctx, message, formatAdminUsers(users))
+}
+
+func formatAdminUsers(users []*models.User) string {
+	if len(users) == 0 {
+		return "There are no users yet."
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d users:\n", len(users))
+
+	for i, user := range users {
+		if i == adminUsersLimit {
+			fmt.Fprintf(&builder, "\n… and %d more", len(users)-adminUsersLimit)
+			break
+		}
+
+		fmt.Fprintf(&builder, "\n#%d · %s · %d", user.ID, formatUserName(user), user.TelegramChatID)
+		if user.Status != models.UserStatusActive {
+			builder.WriteString(" · " + string(user.Status))
+		}
+		if user.IsBlocked {
+			builder.WriteString(" · blocked")
+		}
+	}
+
+	return builder.String()
+}
+
+func formatUserName(user *models.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if user.Username == "" {
+		return name
+	}
+	if name == "" {
+		return "@" + user.Username
+	}
+	return name + " (@" + user.Username + ")"
+}
+
+func (app *App) handleAdminStats(ctx context.Context, message *tgbotapi.Message) {
+	stats, err := app.DB.GetStats()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error retrieving stats", "error", err)
+		return
+	}
+
+	app.sendAdminReply(ctx, message, fmt.Sprintf(
+		"Users: %d (%d blocked)\nPhrases: %d\nReviews: %d (%d today)\nActive sessions: %d",
+		stats.Users,
+		stats.BlockedUsers,
+		stats.Phrases,
+		stats.Reviews,
+		stats.ReviewsToday,
+		stats.ActiveSessions,
+	))
+}
+
+// handleAdminBroadcast queues the text for every active user who isn't
+// blocked, the outbox worker sends it in the background.
+func (app *App) handleAdminBroadcast(ctx context.Context, message *tgbotapi.Message, text string) {
+	if text == "" {
+		app.sendAdminReply(ctx, message, adminUsage)
+		return
+	}
+
+	users, err := app.DB.GetAllUsers()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error retrieving users", "error", err)
+		return
+	}
+
+	queued := 0
+	err = app.DB.Transaction(func(tx database.DatabaseClient) error {
+		for _, user := range users {
+			if !user.CanReview() || user.IsBot {
+				continue
+			}
+
+			if err := enqueueText(tx, user, text); err != nil {
+				return err
+			}
+			queued++
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Queueing the broadcast failed", "error", err)
+		return
+	}
+
+	app.sendAdminReply(ctx, message, fmt.Sprintf("Broadcast queued for %d users.", queued))
+}
+
+func (app *App) handleAdminBlock(ctx context.Context, message *tgbotapi.Message, reference string, blocked bool) {
+	if reference == "" {
+		app.sendAdminReply(ctx, message, adminUsage)
+		return
+	}
+
+	user, err := app.findUserByReference(reference)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user", "error", err)
+		return
+	}
+	if user == nil {
+		app.sendAdminReply(ctx, message, "User "+reference+" not found.")
+		return
+	}
+	if blocked && app.isAdmin(user.TelegramChatID) {
+		app.sendAdminReply(ctx, message, "Admins can't be blocked.")
+		return
+	}
+
+	if err := app.DB.SetUserBlocked(user.ID, blocked); err != nil {
+		slog.ErrorContext(ctx, "Error blocking user", "user_id", user.ID, "error", err)
+		return
+	}
+
+	action := "Blocked"
+	if !blocked {
+		action = "Unblocked"
+	}
+	app.sendAdminReply(ctx, message, fmt.Sprintf("%s %s.", action, formatUserName(user)))
+}
+
+func (app *App) handleAdminAccess(ctx context.Context, message *tgbotapi.Message, reference string, status models.UserStatus) {
+	if reference == "" {
+		app.sendAdminReply(ctx, message, adminUsage)
+		return
+	}
+
+	user, err := app.findUserByReference(reference)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user", "error", err)
+		return
+	}
+	if user == nil {
+		app.sendAdminReply(ctx, message, "User "+reference+" not found.")
+		return
+	}
+
+	app.sendAdminReply(ctx, message, app.setUserAccess(ctx, user, status))
+}
+
+// findUserByReference finds a user by their ID or @username.
+func (app *App) findUserByReference(reference string) (*models.User, error) {
+	if username, ok := strings.CutPrefix(reference, "@"); ok {
+		return app.DB.FindUserByUsername(username)
+	}
+
+	userID, err := strconv.ParseUint(reference, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+
+	return app.DB.FindUser(uint(userID))
+}
+
+// isBlockedSender tells whether the update comes from a user an admin blocked.
+func (app *App) isBlockedSender(ctx context.Context, update tgbotapi.Update) bool {
+	from := update.SentFrom()
+	if from == nil {
+		return false
+	}
+
+	user, err := app.DB.FindUserByTelegramID(from.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return false
+	}
+
+	return user != nil && user.IsBlocked
+}
+
+func (app *App) sendAdminReply(ctx context.Context, message *tgbotapi.Message, text string) {
+	if err := app.SendText(message.Chat.ID, text); err != nil {
+		slog.ErrorContext(ctx, "Sending the admin reply failed", "error", err)
+	}
+}
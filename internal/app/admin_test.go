@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUserByReference(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	user, err := db.CreateUser(&models.User{TelegramChatID: 789, Username: "Learner"})
+	assert.NoError(t, err)
+
+	found, err := setup.app.findUserByReference("@learner")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	found, err = setup.app.findUserByReference("1")
+	assert.NoError(t, err)
+	assert.Equal(t, setup.user.ID, found.ID)
+
+	found, err = setup.app.findUserByReference("nobody")
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestIsBlockedSender(t *testing.T) {
+	setup := setupTestReview(t)
+	update := tgbotapi.Update{Message: &tgbotapi.Message{
+		MessageID: 999,
+		From:      &tgbotapi.User{ID: setup.user.TelegramChatID},
+		Text:      "Guten Morgen #german",
+	}}
+
+	assert.False(t, setup.app.isBlockedSender(context.Background(), update))
+
+	assert.NoError(t, setup.app.DB.SetUserBlocked(setup.user.ID, true))
+	assert.True(t, setup.app.isBlockedSender(context.Background(), update))
+
+	// Updates from blocked users are dropped before a phrase gets saved
+	setup.app.handleUpdate(update)
+	assert.Nil(t, setup.app.DB.FindPhraseByMessageId(setup.user.TelegramChatID, update.Message.MessageID))
+}
+
+func TestGetStats(t *testing.T) {
+	setup := setupTestReview(t)
+
+	assert.NoError(t, setup.app.DB.SetUserBlocked(setup.user.ID, true))
+	_, err := setup.app.ReviewPhrase(setup.phrase.ID, setup.user.ID, 1, models.QualityPerfect)
+	assert.NoError(t, err)
+
+	stats, err := setup.app.DB.GetStats()
+	assert.NoError(t, err)
+	assert.Equal(t, models.Stats{
+		Users:        1,
+		BlockedUsers: 1,
+		Phrases:      1,
+		Reviews:      1,
+		ReviewsToday: 1,
+	}, *stats)
+}
+
+func TestAdminBroadcastIsQueued(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+
+	blocked, err := setup.app.DB.CreateUser(&models.User{TelegramChatID: 789})
+	assert.NoError(t, err)
+	assert.NoError(t, setup.app.DB.SetUserBlocked(blocked.ID, true))
+
+	// Users who weren't let in or left the bot don't get broadcasts either
+	for i, status := range []models.UserStatus{models.UserStatusPending, models.UserStatusRejected, models.UserStatusInactive} {
+		_, err := setup.app.DB.CreateUser(&models.User{TelegramChatID: int64(800 + i), Status: status})
+		assert.NoError(t, err)
+	}
+
+	message := newPrivateMessageUpdate(setup.user.TelegramChatID, "/admin broadcast Hallo").Message
+	setup.app.handleAdminBroadcast(context.Background(), message, "Hallo")
+
+	// Only the reply to the admin is sent right away
+	replies := telegram.sent("sendMessage")
+	assert.Len(t, replies, 1)
+	assert.Equal(t, "Broadcast queued for 1 users.", replies[0].Params.Get("text"))
+
+	messages := findOutboxMessages(t, setup.app)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, setup.user.ID, messages[0].UserID)
+	assert.Equal(t, models.OutboxKindText, messages[0].Kind)
+	assert.Equal(t, "Hallo", messages[0].Text)
+	assert.Equal(t, models.OutboxStatusPending, messages[0].Status)
+}
@@ -1,57 +1,99 @@
 package app
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/config"
 	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/internal/telegram"
+	"github.com/kiasaty/phrase-mate/internal/tts"
+	"github.com/kiasaty/phrase-mate/models"
 )
 
 type App struct {
 	DB          database.DatabaseClient
 	TelegramBot *tgbotapi.BotAPI
 	Config      Config
+	TTS         *tts.Engine
+
+	conversationHandlers map[models.ConversationState]conversationHandler
+
+	// limiter keeps the outbox within the rate limits of the Bot API
+	limiter *telegram.Limiter
+
+	// lastPollAt is the Unix time the long-poll loop last heard back from Telegram
+	lastPollAt atomic.Int64
 }
 
 type Config struct {
-	SessionSize     uint
-	MaxIntervalDays int
+	SessionSize        uint
+	MaxIntervalDays    int
+	SessionIdleTimeout time.Duration
+	TTSCommand         string
+	TTSCacheDir        string
+	LogLevel           slog.Level
+	HTTPAddr           string
+	PollTimeout        time.Duration
+	// SchedulerInterval is how often fetch-updates sends the due phrases
+	// itself, zero leaves it to an external scheduler like cron
+	SchedulerInterval time.Duration
+	AdminIDs          []int64
+	AccessMode        AccessMode
+	InviteCodes       []string
 }
 
 func NewApp(databaseClient database.DatabaseClient, telegramBot *tgbotapi.BotAPI, config Config) *App {
-	return &App{
+	app := &App{
 		DB:          databaseClient,
 		TelegramBot: telegramBot,
 		Config:      config,
+		limiter:     telegram.NewLimiter(),
+	}
+
+	app.conversationHandlers = app.newConversationHandlers()
+
+	if config.TTSCommand != "" {
+		app.TTS = tts.NewEngine(config.TTSCommand, config.TTSCacheDir)
 	}
+
+	return app
 }
 
-func GetDefaultConfig() Config {
+// NewConfig picks the settings of the app out of the configuration.
+func NewConfig(cfg *config.Config) Config {
 	return Config{
-		SessionSize:     20,
-		MaxIntervalDays: 365,
+		SessionSize:        cfg.SessionSize,
+		MaxIntervalDays:    cfg.MaxIntervalDays,
+		SessionIdleTimeout: cfg.SessionIdleTimeout,
+		TTSCommand:         cfg.TTSCommand,
+		TTSCacheDir:        cfg.TTSCacheDir,
+		LogLevel:           cfg.LogLevel,
+		HTTPAddr:           cfg.HTTPAddr,
+		PollTimeout:        cfg.PollTimeout,
+		SchedulerInterval:  cfg.SchedulerInterval,
+		AdminIDs:           cfg.AdminIDs,
+		AccessMode:         AccessMode(cfg.AccessMode),
+		InviteCodes:        cfg.InviteCodes,
 	}
 }
 
-func (app *App) HandleCommand() {
-	if len(os.Args) < 2 {
-		fmt.Println("List of existing commands.")
-		os.Exit(1)
-	}
+// Serve handles the Telegram updates along with the outbox worker, and the
+// admin HTTP server and the scheduler when they are configured, until the
+// process exits.
+func (app *App) Serve() {
+	slog.Info("Searching phrases", "backend", database.SearchBackend)
+
+	go app.runOutboxWorker()
 
-	command := os.Args[1]
-
-	switch command {
-	case "fetch-updates":
-		app.FetchTelegramUpdates()
-	case "send-due-phrases-to-review":
-		app.SendNextPhraseToReviewForAllUsers()
-	case "migrate-database":
-		app.DB.Migrate()
-	default:
-		log.Println("Undefined command:", command)
-		os.Exit(1)
+	if app.Config.HTTPAddr != "" {
+		go app.serveHTTP()
 	}
+	if app.Config.SchedulerInterval > 0 {
+		go app.runScheduler()
+	}
+
+	app.FetchTelegramUpdates()
 }
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/i18n"
+	"github.com/kiasaty/phrase-mate/models"
+	"golang.org/x/text/message"
+)
+
+const recentSessionsLimit = 10
+
+func (app *App) handleBotCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := app.SaveUser(message.From)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error saving user", "error", err)
+		return
+	}
+
+	if isGroupChat(message.Chat) {
+		app.handleGroupCommand(ctx, user, message)
+		return
+	}
+
+	switch message.Command() {
+	case "start":
+		if token := strings.TrimSpace(message.CommandArguments()); token != "" && !app.isInviteCode(token) {
+			app.joinDeck(ctx, user, message, token)
+		}
+	case "deck":
+		app.handleDeckCommand(ctx, user, message)
+	case "decks":
+		app.handleDecksCommand(ctx, user, message)
+	case "sessions":
+		app.handleSessionsCommand(ctx, user, message)
+	case "search":
+		app.handleSearchCommand(ctx, user, message)
+	case "list":
+		app.handleListCommand(ctx, user, message)
+	case "phrase":
+		app.handlePhraseCommand(ctx, user, message)
+	case "tts":
+		app.handleTTSCommand(ctx, user, message)
+	case "mode":
+		app.handleModeCommand(ctx, user, message)
+	case "language":
+		app.handleLanguageCommand(ctx, user, message)
+	case "cancel":
+		app.handleCancelCommand(ctx, user, message)
+	case "admin":
+		app.handleAdminCommand(ctx, user, message)
+	default:
+		slog.WarnContext(ctx, "Undefined bot command", "command", message.Command())
+	}
+}
+
+func (app *App) handleSessionsCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	sessions, err := app.DB.FindRecentSessions(user.ID, recentSessionsLimit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error retrieving sessions", "error", err)
+		return
+	}
+
+	if err := app.SendText(message.Chat.ID, formatSessions(printer(user), sessions)); err != nil {
+		slog.ErrorContext(ctx, "Sending the sessions failed", "error", err)
+	}
+}
+
+func formatSessions(p *message.Printer, sessions []*models.Session) string {
+	if len(sessions) == 0 {
+		return p.Sprintf("You have no review sessions yet.")
+	}
+
+	var builder strings.Builder
+	builder.WriteString(p.Sprintf("Your recent sessions:") + "\n")
+
+	for _, session := range sessions {
+		fmt.Fprintf(
+			&builder,
+			"\n#%d · %s · %s",
+			session.ID,
+			session.StartedAt.Format("2006-01-02 15:04"),
+			formatSessionStatus(p, session),
+		)
+
+		if session.EndedAt != nil {
+			builder.WriteString(p.Sprintf(
+				" · %d reviewed · avg %.1f",
+				session.ReviewedCount,
+				session.AverageQuality,
+			))
+		}
+	}
+
+	return builder.String()
+}
+
+func formatSessionStatus(p *message.Printer, session *models.Session) string {
+	if session.EndedAt == nil {
+		return p.Sprintf("active")
+	}
+
+	switch session.EndReason {
+	case models.SessionEndCompleted:
+		return p.Sprintf("completed")
+	case models.SessionEndExhausted:
+		return p.Sprintf("exhausted")
+	case models.SessionEndTimeout:
+		return p.Sprintf("timeout")
+	case models.SessionEndForced:
+		return p.Sprintf("forced")
+	default:
+		return string(session.EndReason)
+	}
+}
+
+// handleTTSCommand turns text-to-speech voice notes on or off, either for all
+// of the user's phrases or, with a #tag, for the phrases filed under it.
+func (app *App) handleTTSCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	p := printer(user)
+	arguments := strings.Fields(strings.ToLower(message.CommandArguments()))
+	if len(arguments) == 0 || (arguments[0] != "on" && arguments[0] != "off") {
+		if err := app.SendText(message.Chat.ID, p.Sprintf("Usage: /tts on|off [#tag]")); err != nil {
+			slog.ErrorContext(ctx, "Sending the tts usage failed", "error", err)
+		}
+		return
+	}
+
+	enabled := arguments[0] == "on"
+	hashtags := extractHashtags(message.CommandArguments())
+
+	var err error
+	var reply string
+	if len(hashtags) == 0 {
+		err = app.DB.SetUserTTSEnabled(user.ID, enabled)
+		reply = p.Sprintf("Voice notes turned off for all your phrases.")
+		if enabled {
+			reply = p.Sprintf("Voice notes turned on for all your phrases.")
+		}
+	} else {
+		var tags []models.Tag
+		tags, err = app.findOrCreateTags(hashtags)
+		for _, tag := range tags {
+			if err != nil {
+				break
+			}
+			err = app.DB.SetUserTagTTSEnabled(user.ID, tag.ID, enabled)
+		}
+		reply = p.Sprintf("Voice notes turned off for %s.", strings.Join(hashtags, " "))
+		if enabled {
+			reply = p.Sprintf("Voice notes turned on for %s.", strings.Join(hashtags, " "))
+		}
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Updating the tts setting failed", "error", err)
+		return
+	}
+
+	if app.TTS == nil {
+		reply += "\n" + p.Sprintf("Text-to-speech isn't configured on this bot yet, so no voice notes will be attached.")
+	}
+
+	if err := app.SendText(message.Chat.ID, reply); err != nil {
+		slog.ErrorContext(ctx, "Sending the tts confirmation failed", "error", err)
+	}
+}
+
+func (app *App) handleModeCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	p := printer(user)
+	mode := models.ReviewMode(strings.ToLower(strings.TrimSpace(message.CommandArguments())))
+	if !mode.IsValid() {
+		text := p.Sprintf("Your review mode is %s.", user.ReviewMode) + "\n\n" + p.Sprintf("Usage: /mode rate|quiz|typed")
+		if err := app.SendText(message.Chat.ID, text); err != nil {
+			slog.ErrorContext(ctx, "Sending the mode usage failed", "error", err)
+		}
+		return
+	}
+
+	if err := app.DB.SetUserReviewMode(user.ID, mode); err != nil {
+		slog.ErrorContext(ctx, "Updating the review mode failed", "error", err)
+		return
+	}
+
+	reply := p.Sprintf("Review mode set to %s.", mode)
+	switch mode {
+	case models.ReviewModeQuiz:
+		reply += "\n" + p.Sprintf("Two-sided phrases, written as \"front = back\", are asked as multiple choice questions.")
+	case models.ReviewModeTyped:
+		reply += "\n" + p.Sprintf("For two-sided phrases, written as \"front = back\", type the back when you get the front.")
+	}
+
+	if err := app.SendText(message.Chat.ID, reply); err != nil {
+		slog.ErrorContext(ctx, "Sending the mode confirmation failed", "error", err)
+	}
+}
+
+// handleLanguageCommand picks the language the bot talks to the user in, auto
+// goes back to the language of their Telegram app.
+func (app *App) handleLanguageCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	argument := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+
+	var language string
+	switch {
+	case argument == "auto":
+	case argument != "" && i18n.IsSupported(argument):
+		language = i18n.Match(argument).String()
+	default:
+		p := printer(user)
+		text := p.Sprintf("The bot speaks %s to you.", i18n.Name(user.PreferredLanguage())) + "\n\n" +
+			p.Sprintf("Usage: /language %s|auto", strings.Join(i18n.Codes(), "|"))
+		if err := app.SendText(message.Chat.ID, text); err != nil {
+			slog.ErrorContext(ctx, "Sending the language usage failed", "error", err)
+		}
+		return
+	}
+
+	if err := app.DB.SetUserLanguage(user.ID, language); err != nil {
+		slog.ErrorContext(ctx, "Updating the language failed", "error", err)
+		return
+	}
+	user.Language = language
+
+	// Confirm in the language that was just picked
+	p := printer(user)
+	reply := p.Sprintf("The bot now speaks %s.", i18n.Name(language))
+	if language == "" {
+		reply = p.Sprintf("The bot now follows the language of your Telegram app.")
+	}
+
+	if err := app.SendText(message.Chat.ID, reply); err != nil {
+		slog.ErrorContext(ctx, "Sending the language confirmation failed", "error", err)
+	}
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguageCommand(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	ctx := context.Background()
+	setup.user.LanguageCode = "es"
+
+	// Without a choice the bot follows the Telegram app
+	setup.app.handleLanguageCommand(ctx, setup.user, newPrivateMessageUpdate(setup.user.TelegramChatID, "/language").Message)
+	sent := telegram.sent("sendMessage")
+	assert.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Params.Get("text"), "Uso: /language en|de|fa|es|auto")
+
+	setup.app.handleLanguageCommand(ctx, setup.user, newPrivateMessageUpdate(setup.user.TelegramChatID, "/language de-AT").Message)
+	user, err := setup.app.DB.FindUserByTelegramID(setup.user.TelegramChatID)
+	assert.NoError(t, err)
+	assert.Equal(t, "de", user.Language)
+	assert.Equal(t, "de", user.PreferredLanguage())
+	assert.Equal(t, "Der Bot spricht jetzt Deutsch.", telegram.sent("sendMessage")[1].Params.Get("text"))
+
+	// Unsupported languages keep the current choice
+	setup.app.handleLanguageCommand(ctx, setup.user, newPrivateMessageUpdate(setup.user.TelegramChatID, "/language ja").Message)
+	user, err = setup.app.DB.FindUserByTelegramID(setup.user.TelegramChatID)
+	assert.NoError(t, err)
+	assert.Equal(t, "de", user.Language)
+
+	setup.app.handleLanguageCommand(ctx, setup.user, newPrivateMessageUpdate(setup.user.TelegramChatID, "/language auto").Message)
+	user, err = setup.app.DB.FindUserByTelegramID(setup.user.TelegramChatID)
+	assert.NoError(t, err)
+	assert.Empty(t, user.Language)
+	assert.Equal(t, "El bot ahora sigue el idioma de tu app de Telegram.", telegram.sent("sendMessage")[3].Params.Get("text"))
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+// conversationHandler handles the message a chat sends while its
+// conversation is in a given state. Conversations left unanswered longer than
+// the timeout are dropped and the chat goes back to saving phrases. Messages
+// the handler doesn't accept are left to the phrase handling and the
+// conversation stays open; without Accepts any text message is taken.
+type conversationHandler struct {
+	Timeout time.Duration
+	Accepts func(message *tgbotapi.Message) bool
+	Handle  func(ctx context.Context, message *tgbotapi.Message, conversation *models.Conversation)
+}
+
+func (app *App) newConversationHandlers() map[models.ConversationState]conversationHandler {
+	return map[models.ConversationState]conversationHandler{
+		models.ConversationAwaitingAnswer: {
+			Timeout: app.Config.SessionIdleTimeout,
+			Accepts: isAnswerMessage,
+			Handle:  app.handleTypedAnswer,
+		},
+		models.ConversationAwaitingTagName: {
+			Timeout: 10 * time.Minute,
+			Accepts: isTagNameMessage,
+			Handle:  app.handleTagNameReply,
+		},
+	}
+}
+
+// isAnswerMessage reports whether the message can be a typed answer, messages
+// with hashtags are new phrases.
+func isAnswerMessage(message *tgbotapi.Message) bool {
+	return message.Text != "" && len(extractHashtags(message.Text)) == 0
+}
+
+// isTagNameMessage reports whether the message only names tags, with or
+// without the leading #, rather than being a new phrase with its hashtags.
+func isTagNameMessage(message *tgbotapi.Message) bool {
+	return message.Text != "" &&
+		(len(extractHashtags(message.Text)) == 0 || removeHashtags(message.Text) == "")
+}
+
+// startConversation makes the chat's next message go to the handler of the
+// given state, replacing any conversation already going on in the chat.
+func (app *App) startConversation(chatID int64, user *models.User, state models.ConversationState, sessionID uint, phraseID uint) error {
+	handler, ok := app.conversationHandlers[state]
+	if !ok {
+		return nil
+	}
+
+	return app.DB.SaveConversation(&models.Conversation{
+		TelegramChatID: chatID,
+		UserID:         user.ID,
+		State:          state,
+		SessionID:      sessionID,
+		PhraseID:       phraseID,
+		ExpiresAt:      time.Now().Add(handler.Timeout),
+	})
+}
+
+// handleConversation passes the message to the handler of the chat's
+// conversation. It reports whether the message was handled, commands always
+// go through so the user can /cancel or move on, and so do media and new
+// phrases the handler doesn't accept.
+func (app *App) handleConversation(ctx context.Context, message *tgbotapi.Message) bool {
+	if message.Text == "" || message.IsCommand() {
+		return false
+	}
+
+	conversation, err := app.DB.FindConversation(message.Chat.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding the conversation", "error", err)
+		return false
+	}
+	if conversation == nil {
+		return false
+	}
+
+	handler, ok := app.conversationHandlers[conversation.State]
+	if ok && !conversation.IsExpired() && handler.Accepts != nil && !handler.Accepts(message) {
+		return false
+	}
+
+	// The conversation is over either way, the handler may start a new one
+	if err := app.DB.DeleteConversation(conversation.TelegramChatID); err != nil {
+		slog.ErrorContext(ctx, "Failed to clear the conversation", "error", err)
+		return false
+	}
+
+	if !ok || conversation.IsExpired() {
+		return false
+	}
+
+	handler.Handle(ctx, message, conversation)
+	return true
+}
+
+func (app *App) handleCancelCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	if err := app.DB.DeleteConversation(message.Chat.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to clear the conversation", "error", err)
+		return
+	}
+
+	if err := app.SendText(message.Chat.ID, printer(user).Sprintf("Cancelled.")); err != nil {
+		slog.ErrorContext(ctx, "Sending the cancel confirmation failed", "error", err)
+	}
+}
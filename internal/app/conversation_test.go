@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleConversation(t *testing.T) {
+	setup := setupTestReview(t)
+
+	var handled []*models.Conversation
+	setup.app.conversationHandlers[models.ConversationAwaitingTagName] = conversationHandler{
+		Timeout: time.Minute,
+		Handle: func(ctx context.Context, message *tgbotapi.Message, conversation *models.Conversation) {
+			handled = append(handled, conversation)
+		},
+	}
+
+	chatID := setup.user.TelegramChatID
+	message := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, Text: "#german"}
+
+	// Without a conversation the message is left to the phrase handling
+	assert.False(t, setup.app.handleConversation(context.Background(), message))
+
+	err := setup.app.startConversation(chatID, setup.user, models.ConversationAwaitingTagName, 0, setup.phrase.ID)
+	assert.NoError(t, err)
+
+	assert.True(t, setup.app.handleConversation(context.Background(), message))
+	assert.Len(t, handled, 1)
+	assert.Equal(t, setup.phrase.ID, handled[0].PhraseID)
+
+	// The conversation ends once handled
+	assert.False(t, setup.app.handleConversation(context.Background(), message))
+}
+
+func TestHandleConversationTimeout(t *testing.T) {
+	setup := setupTestReview(t)
+
+	setup.app.conversationHandlers[models.ConversationAwaitingTagName] = conversationHandler{
+		Timeout: -time.Minute,
+		Handle: func(ctx context.Context, message *tgbotapi.Message, conversation *models.Conversation) {
+			t.Error("Expected the expired conversation not to be handled")
+		},
+	}
+
+	chatID := setup.user.TelegramChatID
+	err := setup.app.startConversation(chatID, setup.user, models.ConversationAwaitingTagName, 0, setup.phrase.ID)
+	assert.NoError(t, err)
+
+	message := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, Text: "#german"}
+	assert.False(t, setup.app.handleConversation(context.Background(), message))
+
+	conversation, err := setup.app.DB.FindConversation(chatID)
+	assert.NoError(t, err)
+	assert.Nil(t, conversation)
+}
+
+func TestNewPhrasesGoThroughAwaitedAnswers(t *testing.T) {
+	setup := setupTestReview(t)
+	setupTestTelegram(t, setup.app)
+
+	chatID := setup.user.TelegramChatID
+	err := setup.app.startConversation(chatID, setup.user, models.ConversationAwaitingAnswer, 1, setup.phrase.ID)
+	assert.NoError(t, err)
+
+	update := newPrivateMessageUpdate(chatID, "Apfel #german")
+	assert.False(t, setup.app.handleConversation(context.Background(), update.Message))
+
+	setup.app.handleUpdate(update)
+	phrase := setup.app.DB.FindPhraseByMessageId(chatID, update.Message.MessageID)
+	assert.NotNil(t, phrase)
+	assert.Equal(t, "Apfel #german", phrase.Text)
+
+	// The answer is still awaited
+	conversation, err := setup.app.DB.FindConversation(chatID)
+	assert.NoError(t, err)
+	assert.NotNil(t, conversation)
+	assert.Equal(t, models.ConversationAwaitingAnswer, conversation.State)
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+const deckUsage = "Usage:\n/deck new #tag - share your #tag phrases as a deck\n/deck leave <id> - leave a deck\n/decks - list your decks"
+
+func (app *App) handleDeckCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	arguments := strings.Fields(message.CommandArguments())
+	if len(arguments) != 2 {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf(deckUsage))
+		return
+	}
+
+	switch strings.ToLower(arguments[0]) {
+	case "new":
+		app.createDeck(ctx, user, message, arguments[1])
+	case "leave":
+		app.leaveDeck(ctx, user, message, arguments[1])
+	default:
+		app.sendDeckReply(ctx, message, printer(user).Sprintf(deckUsage))
+	}
+}
+
+func (app *App) createDeck(ctx context.Context, user *models.User, message *tgbotapi.Message, hashtag string) {
+	if !strings.HasPrefix(hashtag, "#") {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf(deckUsage))
+		return
+	}
+	name := strings.ToLower(hashtag)
+
+	existingDeck, err := app.DB.FindOwnedDeckByName(user.ID, name)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding deck", "error", err)
+		return
+	}
+	if existingDeck != nil {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf("You already share %s: %s", name, app.deckInviteLink(existingDeck)))
+		return
+	}
+
+	token, err := generateInviteToken()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating invite token", "error", err)
+		return
+	}
+
+	deck := &models.Deck{
+		OwnerID:     user.ID,
+		Name:        name,
+		InviteToken: token,
+	}
+
+	err = app.DB.Transaction(func(tx database.DatabaseClient) error {
+		if _, err := tx.CreateDeck(deck); err != nil {
+			return err
+		}
+		if err := tx.AddDeckMember(deck.ID, user.ID); err != nil {
+			return err
+		}
+		return tx.AssignTagPhrasesToDeck(deck)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating deck", "error", err)
+		return
+	}
+
+	app.sendDeckReply(ctx,
+		message,
+		printer(user).Sprintf("Your %s phrases are now a shared deck. Invite others with this link:", name)+"\n"+app.deckInviteLink(deck),
+	)
+}
+
+func (app *App) leaveDeck(ctx context.Context, user *models.User, message *tgbotapi.Message, argument string) {
+	deckID, err := strconv.Atoi(argument)
+	if err != nil {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf(deckUsage))
+		return
+	}
+
+	deck, err := app.DB.FindDeck(uint(deckID))
+	if err != nil {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf("Deck not found."))
+		return
+	}
+
+	if deck.OwnerID == user.ID {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf("You own this deck, so you can't leave it."))
+		return
+	}
+
+	isMember, err := app.DB.IsDeckMember(deck.ID, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking the deck membership", "error", err)
+		return
+	}
+	if !isMember {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf("You are not a member of this deck."))
+		return
+	}
+
+	if err := app.DB.RemoveDeckMember(deck.ID, user.ID); err != nil {
+		slog.ErrorContext(ctx, "Error leaving deck", "error", err)
+		return
+	}
+
+	app.sendDeckReply(ctx, message, printer(user).Sprintf("You left %s.", deck.Name))
+}
+
+func (app *App) handleDecksCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	decks, err := app.DB.FindUserDecks(user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error retrieving decks", "error", err)
+		return
+	}
+
+	p := printer(user)
+	if len(decks) == 0 {
+		app.sendDeckReply(ctx, message, p.Sprintf("You are not in any deck yet.")+"\n\n"+p.Sprintf(deckUsage))
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString(p.Sprintf("Your decks:") + "\n")
+	for _, deck := range decks {
+		fmt.Fprintf(&builder, "\n%d) %s · %s", deck.ID, deck.Name, p.Sprintf("%d members", len(deck.Members)))
+		if deck.OwnerID == user.ID {
+			builder.WriteString(" · " + p.Sprintf("invite: %s", app.deckInviteLink(deck)))
+		}
+	}
+
+	app.sendDeckReply(ctx, message, builder.String())
+}
+
+// joinDeck handles the /start <invite-token> deep links of deck invites.
+func (app *App) joinDeck(ctx context.Context, user *models.User, message *tgbotapi.Message, token string) {
+	deck, err := app.DB.FindDeckByInviteToken(token)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding deck", "error", err)
+		return
+	}
+	if deck == nil {
+		app.sendDeckReply(ctx, message, printer(user).Sprintf("This invite link is not valid."))
+		return
+	}
+
+	if err := app.DB.AddDeckMember(deck.ID, user.ID); err != nil {
+		slog.ErrorContext(ctx, "Error joining deck", "error", err)
+		return
+	}
+
+	app.sendDeckReply(ctx, message, printer(user).Sprintf("You joined %s shared by %s. Its phrases will show up in your reviews.", deck.Name, deck.Owner.FirstName))
+}
+
+// findPhraseDeck returns the deck the owner shares under one of the hashtags,
+// so new phrases land in it.
+func (app *App) findPhraseDeck(user *models.User, hashtags []string) (*models.Deck, error) {
+	for _, hashtag := range hashtags {
+		deck, err := app.DB.FindOwnedDeckByName(user.ID, strings.ToLower(hashtag))
+		if err != nil || deck != nil {
+			return deck, err
+		}
+	}
+	return nil, nil
+}
+
+func (app *App) deckInviteLink(deck *models.Deck) string {
+	return "https://t.me/" + app.TelegramBot.Self.UserName + "?start=" + deck.InviteToken
+}
+
+func (app *App) sendDeckReply(ctx context.Context, message *tgbotapi.Message, text string) {
+	if err := app.SendText(message.Chat.ID, text); err != nil {
+		slog.ErrorContext(ctx, "Sending the deck reply failed", "error", err)
+	}
+}
+
+func generateInviteToken() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
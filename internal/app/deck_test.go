@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeckPhrasesAreSharedWithMembers(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	german, err := db.CreateTag(&models.Tag{Name: "#german"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.UpdatePhraseTags(setup.phrase, &[]models.Tag{*german}))
+
+	member, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+	memberSession := &models.Session{UserID: member.ID}
+
+	// Phrases of other users are not reviewed before joining their deck
+	phrase, err := setup.app.getNextPhraseToReview(memberSession)
+	assert.NoError(t, err)
+	assert.Nil(t, phrase)
+
+	deck, err := db.CreateDeck(&models.Deck{OwnerID: setup.user.ID, Name: "#german", InviteToken: "token"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AssignTagPhrasesToDeck(deck))
+	assert.NoError(t, db.AddDeckMember(deck.ID, setup.user.ID))
+	assert.NoError(t, db.AddDeckMember(deck.ID, member.ID))
+
+	phrase, err = setup.app.getNextPhraseToReview(memberSession)
+	assert.NoError(t, err)
+	assert.NotNil(t, phrase)
+	assert.Equal(t, setup.phrase.ID, phrase.ID)
+
+	// Members keep their own schedule but can't edit the shared phrase
+	_, err = setup.app.ReviewPhrase(phrase.ID, member.ID, 1, models.QualityPerfect)
+	assert.NoError(t, err)
+	assert.NoError(t, setup.app.applyPhraseAction(member, phrase, phraseActionReset))
+	assert.Error(t, setup.app.applyPhraseAction(member, phrase, phraseActionDelete))
+
+	ownerPhrase, err := setup.app.getNextPhraseToReview(&models.Session{UserID: setup.user.ID})
+	assert.NoError(t, err)
+	assert.NotNil(t, ownerPhrase)
+}
+
+// reviewYesterday reviews the phrase for the user and makes it due again.
+func reviewYesterday(t *testing.T, app *App, userID uint, phraseID uint) {
+	review, err := app.ReviewPhrase(phraseID, userID, 1, models.QualityForgot)
+	assert.NoError(t, err)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	review.NextReviewAt = &yesterday
+	assert.NoError(t, app.DB.UpdateReview(review))
+}
+
+func TestLeftDeckPhrasesAreNoLongerDue(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+
+	german, err := db.CreateTag(&models.Tag{Name: "#german"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.UpdatePhraseTags(setup.phrase, &[]models.Tag{*german}))
+
+	member, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+	deck, err := db.CreateDeck(&models.Deck{OwnerID: setup.user.ID, Name: "#german", InviteToken: "token"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AssignTagPhrasesToDeck(deck))
+	assert.NoError(t, db.AddDeckMember(deck.ID, setup.user.ID))
+	assert.NoError(t, db.AddDeckMember(deck.ID, member.ID))
+
+	reviewYesterday(t, setup.app, member.ID, setup.phrase.ID)
+	count, err := db.CountDueReviews(member.ID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), count)
+
+	// Leaving takes being a member, the deck's name isn't given away otherwise
+	stranger, err := db.CreateUser(&models.User{TelegramChatID: 654})
+	assert.NoError(t, err)
+	message := newPrivateMessageUpdate(stranger.TelegramChatID, "/deck leave "+strconv.Itoa(int(deck.ID))).Message
+	setup.app.handleDeckCommand(context.Background(), stranger, message)
+	sent := telegram.sent("sendMessage")
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "You are not a member of this deck.", sent[0].Params.Get("text"))
+
+	message = newPrivateMessageUpdate(member.TelegramChatID, "/deck leave "+strconv.Itoa(int(deck.ID))).Message
+	setup.app.handleDeckCommand(context.Background(), member, message)
+	sent = telegram.sent("sendMessage")
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "You left #german.", sent[1].Params.Get("text"))
+
+	count, err = db.CountDueReviews(member.ID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), count)
+
+	phrase, err := setup.app.getNextPhraseToReview(&models.Session{UserID: member.ID})
+	assert.NoError(t, err)
+	assert.Nil(t, phrase)
+}
+
+func TestGroupPhrasesAreOnlyReviewedByMembers(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	groupChatID := int64(-100)
+	deck, err := db.CreateDeck(&models.Deck{
+		OwnerID:        setup.user.ID,
+		Name:           "Team",
+		InviteToken:    "group-token",
+		TelegramChatID: &groupChatID,
+	})
+	assert.NoError(t, err)
+
+	setup.phrase.DeckID = &deck.ID
+	assert.NoError(t, db.UpdatePhrase(setup.phrase))
+
+	// Posting in the group doesn't opt the poster in
+	session := &models.Session{UserID: setup.user.ID}
+	phrase, err := setup.app.getNextPhraseToReview(session)
+	assert.NoError(t, err)
+	assert.Nil(t, phrase)
+
+	assert.NoError(t, db.AddDeckMember(deck.ID, setup.user.ID))
+
+	phrase, err = setup.app.getNextPhraseToReview(session)
+	assert.NoError(t, err)
+	assert.NotNil(t, phrase)
+
+	groupDeck, err := db.FindDeckByTelegramChatID(groupChatID)
+	assert.NoError(t, err)
+	assert.True(t, groupDeck.IsGroupDeck())
+}
+
+func TestLeftGroupPhrasesAreNoLongerDue(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+
+	groupChatID := int64(-100)
+	deck, err := db.CreateDeck(&models.Deck{
+		OwnerID:        setup.user.ID,
+		Name:           "Team",
+		InviteToken:    "group-token",
+		TelegramChatID: &groupChatID,
+	})
+	assert.NoError(t, err)
+	setup.phrase.DeckID = &deck.ID
+	assert.NoError(t, db.UpdatePhrase(setup.phrase))
+
+	member, err := db.CreateUser(&models.User{TelegramChatID: 321, FirstName: "Member"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AddDeckMember(deck.ID, member.ID))
+	reviewYesterday(t, setup.app, member.ID, setup.phrase.ID)
+
+	count, err := db.CountDueReviews(member.ID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), count)
+
+	message := newPrivateMessageUpdate(member.TelegramChatID, "/leave").Message
+	message.Chat = &tgbotapi.Chat{ID: groupChatID, Type: "group"}
+	setup.app.handleGroupCommand(context.Background(), member, message)
+	assert.Len(t, telegram.sent("sendMessage"), 1)
+
+	count, err = db.CountDueReviews(member.ID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), count)
+}
+
+func TestGroupsWithTheSameTitleGetTheirOwnDecks(t *testing.T) {
+	setup := setupTestReview(t)
+
+	first, err := setup.app.getOrCreateGroupDeck(&tgbotapi.Chat{ID: -100, Type: "group", Title: "Team"}, setup.user)
+	assert.NoError(t, err)
+	second, err := setup.app.getOrCreateGroupDeck(&tgbotapi.Chat{ID: -200, Type: "group", Title: "Team"}, setup.user)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	again, err := setup.app.getOrCreateGroupDeck(&tgbotapi.Chat{ID: -100, Type: "group", Title: "Team"}, setup.user)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID)
+}
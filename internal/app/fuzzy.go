@@ -0,0 +1,70 @@
+package app
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizeAnswer lowercases the text, strips accents and punctuation and
+// collapses whitespace, so only the words themselves are compared.
+func normalizeAnswer(text string) string {
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	stripped, _, err := transform.String(stripAccents, text)
+	if err != nil {
+		stripped = text
+	}
+
+	stripped = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return ' '
+		}
+		return unicode.ToLower(r)
+	}, stripped)
+
+	return strings.Join(strings.Fields(stripped), " ")
+}
+
+// answerSimilarity returns how close the answer is to the expected text, from
+// 0 for completely different to 1 for equal, using the normalized Levenshtein
+// distance.
+func answerSimilarity(answer string, expected string) float64 {
+	a := []rune(normalizeAnswer(answer))
+	b := []rune(normalizeAnswer(expected))
+
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
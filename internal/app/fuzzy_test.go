@@ -0,0 +1,26 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	assert.Equal(t, "cafe creme", normalizeAnswer("  Café,  Crème! "))
+	assert.Equal(t, "uber", normalizeAnswer("Über"))
+}
+
+func TestAnswerSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, answerSimilarity("the dog", "The Dog!"))
+	assert.Equal(t, 1.0, answerSimilarity("Ubung", "Übung"))
+	assert.InDelta(t, 0.857, answerSimilarity("the dag", "the dog"), 0.001)
+	assert.Equal(t, 0.0, answerSimilarity("abc", "xyz"))
+	assert.Equal(t, 0.0, answerSimilarity("", "dog"))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 3, levenshtein([]rune("kitten"), []rune("sitting")))
+	assert.Equal(t, 0, levenshtein([]rune("dog"), []rune("dog")))
+	assert.Equal(t, 3, levenshtein([]rune(""), []rune("dog")))
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+func isGroupChat(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// getOrCreateGroupDeck returns the deck collecting the phrases of a group,
+// creating it on the first phrase posted there.
+func (app *App) getOrCreateGroupDeck(chat *tgbotapi.Chat, user *models.User) (*models.Deck, error) {
+	deck, err := app.DB.FindDeckByTelegramChatID(chat.ID)
+	if err != nil || deck != nil {
+		return deck, err
+	}
+
+	token, err := generateInviteToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Deck names are unique per owner and group titles aren't, so the name
+	// carries the chat ID too
+	chatID := chat.ID
+	return app.DB.CreateDeck(&models.Deck{
+		OwnerID:        user.ID,
+		Name:           fmt.Sprintf("%s (%d)", chat.Title, chat.ID),
+		InviteToken:    token,
+		TelegramChatID: &chatID,
+	})
+}
+
+// handleGroupCommand handles the commands sent in a group. Personal commands
+// like /search are left to private chats so nobody's phrases leak into the group.
+func (app *App) handleGroupCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	switch message.Command() {
+	case "join":
+		app.joinGroupDeck(ctx, user, message)
+	case "leave":
+		app.leaveGroupDeck(ctx, user, message)
+	default:
+		slog.WarnContext(ctx, "Undefined group command", "command", message.Command())
+	}
+}
+
+func (app *App) joinGroupDeck(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	deck, err := app.getOrCreateGroupDeck(message.Chat, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding the group deck", "error", err)
+		return
+	}
+
+	if err := app.DB.AddDeckMember(deck.ID, user.ID); err != nil {
+		slog.ErrorContext(ctx, "Error joining the group deck", "error", err)
+		return
+	}
+
+	app.sendGroupReply(ctx,
+		message,
+		user.FirstName+" will get the phrases of this group for review in a private chat with @"+
+			app.TelegramBot.Self.UserName+". Make sure to start it there once.",
+	)
+}
+
+func (app *App) leaveGroupDeck(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	deck, err := app.DB.FindDeckByTelegramChatID(message.Chat.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding the group deck", "error", err)
+		return
+	}
+	if deck == nil {
+		return
+	}
+
+	if err := app.DB.RemoveDeckMember(deck.ID, user.ID); err != nil {
+		slog.ErrorContext(ctx, "Error leaving the group deck", "error", err)
+		return
+	}
+
+	app.sendGroupReply(ctx, message, user.FirstName+" won't get the phrases of this group for review anymore.")
+}
+
+func (app *App) sendGroupReply(ctx context.Context, message *tgbotapi.Message, text string) {
+	reply := tgbotapi.NewMessage(message.Chat.ID, text)
+	reply.ReplyToMessageID = message.MessageID
+	if _, err := app.TelegramBot.Send(reply); err != nil {
+		slog.ErrorContext(ctx, "Sending the group reply failed", "error", err)
+	}
+}
@@ -1,15 +1,26 @@
 package app
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/internal/metrics"
 	"github.com/kiasaty/phrase-mate/models"
 )
 
 func (app *App) SaveUser(user *tgbotapi.User) (*models.User, error) {
+	return app.saveUserWithStatus(user, models.UserStatusActive)
+}
+
+// saveUserWithStatus registers the Telegram user with the given status unless
+// they're registered already.
+func (app *App) saveUserWithStatus(user *tgbotapi.User, status models.UserStatus) (*models.User, error) {
 	existingUser, err := app.DB.FindUserByTelegramID(user.ID)
 	if err != nil {
 		return nil, err
@@ -23,6 +34,7 @@ func (app *App) SaveUser(user *tgbotapi.User) (*models.User, error) {
 			Username:       user.UserName,
 			LanguageCode:   user.LanguageCode,
 			IsBot:          user.IsBot,
+			Status:         status,
 		}
 
 		savedUser, err := app.DB.CreateUser(newUser)
@@ -38,123 +50,238 @@ func (app *App) SaveUser(user *tgbotapi.User) (*models.User, error) {
 
 func (app *App) FetchTelegramUpdates() {
 	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	u.Timeout = int(app.Config.PollTimeout.Seconds())
 
-	updates := app.TelegramBot.GetUpdatesChan(u)
-
-	for update := range updates {
-		if update.CallbackQuery != nil {
-			app.handleCallbackQuery(update.CallbackQuery)
+	// Poll in our own loop rather than GetUpdatesChan, so the health check
+	// can tell when the loop got stuck
+	for {
+		updates, err := app.TelegramBot.GetUpdates(u)
+		app.lastPollAt.Store(time.Now().Unix())
+		if err != nil {
+			slog.Error("Fetching updates failed", "error", err)
+			time.Sleep(3 * time.Second)
 			continue
 		}
 
-		if update.Message != nil {
-			app.handleNewPhrase(update.Message)
-			continue
+		for _, update := range updates {
+			if update.UpdateID >= u.Offset {
+				u.Offset = update.UpdateID + 1
+				app.handleUpdate(update)
+			}
 		}
 	}
 }
 
-func (app *App) handleNewPhrase(message *tgbotapi.Message) {
+// handleUpdate routes a single update to its handler. Everything logged while
+// handling it carries the update and chat IDs.
+func (app *App) handleUpdate(update tgbotapi.Update) {
+	ctx := logging.With(context.Background(), "update_id", update.UpdateID)
+	if chat := update.FromChat(); chat != nil {
+		ctx = logging.With(ctx, "chat_id", chat.ID)
+	}
+
+	// Blocked users are ignored altogether, so they can't add phrases either
+	if app.isBlockedSender(ctx, update) {
+		slog.DebugContext(ctx, "Ignoring update from a blocked user")
+		return
+	}
+
+	// Users the access mode doesn't let in never get registered as active
+	if !app.checkAccess(ctx, update) {
+		return
+	}
+
+	start := time.Now()
+
+	if update.CallbackQuery != nil {
+		defer metrics.ObserveUpdate("callback_query", start)
+		app.handleCallbackQuery(ctx, update.CallbackQuery)
+		return
+	}
+
+	if update.Message == nil {
+		return
+	}
+	defer metrics.ObserveUpdate("message", start)
+
+	if app.handleConversation(ctx, update.Message) {
+		return
+	}
+
+	if update.Message.ReplyToMessage != nil && app.handlePhraseReply(ctx, update.Message) {
+		return
+	}
+
+	if update.Message.IsCommand() {
+		app.handleBotCommand(ctx, update.Message)
+		return
+	}
+
+	app.handleNewPhrase(ctx, update.Message)
+}
+
+func (app *App) handleNewPhrase(ctx context.Context, message *tgbotapi.Message) {
 	// Save the user information
 	user, err := app.SaveUser(message.From)
 	if err != nil {
-		log.Printf("Error saving user: %v", err)
+		slog.ErrorContext(ctx, "Error saving user", "error", err)
 		return
 	}
 
-	// Extract message text and hashtags
+	// Extract message text and hashtags, media messages carry them in the caption
 	messageText := message.Text
+	if messageText == "" {
+		messageText = message.Caption
+	}
 	messageID := message.MessageID
 	hashtags := extractHashtags(messageText)
 
 	if len(hashtags) == 0 {
-		log.Printf("No hashtags found in message: %s", messageText)
+		slog.DebugContext(ctx, "No hashtags found in message", "text", messageText)
 		return
 	}
 
 	// Check if the phrase already exists
-	existingPhrase := app.DB.FindPhraseByMessageId(messageID)
+	existingPhrase := app.DB.FindPhraseByMessageId(message.Chat.ID, messageID)
 	if existingPhrase != nil {
-		log.Printf("Phrase with MessageID %d already exists", messageID)
+		slog.InfoContext(ctx, "Phrase already exists", "message_id", messageID)
 		return
 	}
 
 	// Create or find tags
-	var tags []models.Tag
-	for _, hashtag := range hashtags {
-		hashtag := strings.ToLower(hashtag)
-
-		tag, err := app.DB.FindTagByName(hashtag)
-		if err != nil {
-			// Create tag if it doesn't exist
-			tag, err = app.DB.CreateTag(&models.Tag{Name: hashtag})
-			if err != nil {
-				log.Printf("Error creating tag %s: %v", hashtag, err)
-				return
-			}
-		}
-		tags = append(tags, *tag)
+	tags, err := app.findOrCreateTags(hashtags)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating tags", "error", err)
+		return
 	}
 
 	// Create a new phrase with user reference and tags
 	phrase := &models.Phrase{
 		UserID:            user.ID,
+		TelegramChatID:    message.Chat.ID,
 		TelegramMessageID: messageID,
 		Text:              messageText,
 		Tags:              tags,
 	}
+	phrase.MediaType, phrase.FileID = extractMedia(message)
+
+	// Phrases posted in a group go into its deck, the ones filed under a
+	// shared hashtag into the owner's deck
+	var deck *models.Deck
+	if isGroupChat(message.Chat) {
+		deck, err = app.getOrCreateGroupDeck(message.Chat, user)
+	} else {
+		deck, err = app.findPhraseDeck(user, hashtags)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding deck", "error", err)
+		return
+	}
+	if deck != nil {
+		phrase.DeckID = &deck.ID
+	}
 
 	if _, err := app.DB.CreatePhrase(phrase); err != nil {
-		log.Printf("Error creating phrase: %v", err)
+		slog.ErrorContext(ctx, "Error creating phrase", "error", err)
 		return
 	}
+	metrics.PhrasesCreated.Inc()
+
+	slog.InfoContext(ctx, "Phrase added", "user_id", user.ID, "phrase_id", phrase.ID)
+}
+
+func (app *App) findOrCreateTags(hashtags []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	for _, hashtag := range hashtags {
+		hashtag := strings.ToLower(hashtag)
+
+		tag, err := app.DB.FindTagByName(hashtag)
+		if err != nil {
+			// Create tag if it doesn't exist
+			tag, err = app.DB.CreateTag(&models.Tag{Name: hashtag})
+			if err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, *tag)
+	}
 
-	log.Printf("Phrase added for user %s: %s", user.Username, messageText)
+	return tags, nil
 }
 
-func (app *App) handleCallbackQuery(callbackQuery *tgbotapi.CallbackQuery) {
+func (app *App) handleCallbackQuery(ctx context.Context, callbackQuery *tgbotapi.CallbackQuery) {
 	data := strings.Split(callbackQuery.Data, ":")
 
-	if len(data) != 4 || data[0] != "review" {
-		log.Printf("Invalid callback data: %s", callbackQuery.Data)
+	switch data[0] {
+	case "review":
+		app.handleReviewCallback(ctx, callbackQuery, data)
+	case "search", "list":
+		app.handlePhrasesPageCallback(ctx, callbackQuery, data)
+	case "quiz":
+		app.handleQuizCallback(ctx, callbackQuery, data)
+	case "answer":
+		app.handleShowAnswerCallback(ctx, callbackQuery, data)
+	case "phrase":
+		app.handlePhraseActionCallback(ctx, callbackQuery, data)
+	case "access":
+		app.handleAccessCallback(ctx, callbackQuery, data)
+	default:
+		slog.WarnContext(ctx, "Invalid callback data", "data", callbackQuery.Data)
+	}
+}
+
+func (app *App) handleReviewCallback(ctx context.Context, callbackQuery *tgbotapi.CallbackQuery, data []string) {
+	if len(data) != 4 {
+		slog.WarnContext(ctx, "Invalid callback data", "data", callbackQuery.Data)
 		return
 	}
 
 	user, err := app.DB.FindUserByTelegramID(callbackQuery.From.ID)
-	if err != nil {
-		log.Printf("User not found: %v", err)
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
 		return
 	}
 
 	sessionID, err := strconv.Atoi(data[1])
 	if err != nil {
-		log.Printf("Invalid session ID: %v", err)
+		slog.ErrorContext(ctx, "Invalid session ID", "error", err)
 		return
 	}
 
 	phraseID, err := strconv.Atoi(data[2])
 	if err != nil {
-		log.Printf("Invalid phrase ID: %v", err)
+		slog.ErrorContext(ctx, "Invalid phrase ID", "error", err)
 		return
 	}
 
 	recallQualityNumber, err := strconv.ParseUint(data[3], 10, 8)
 	if err != nil {
-		log.Printf("Invalid recall quality: %v", err)
+		slog.ErrorContext(ctx, "Invalid recall quality", "error", err)
 		return
 	}
 
 	recallQuality := models.RecallQuality(recallQualityNumber)
 	if !recallQuality.IsValid() {
-		log.Printf("Invalid RecallQuality value: %v", recallQuality)
+		slog.WarnContext(ctx, "Invalid RecallQuality value", "recall_quality", recallQuality)
+		return
+	}
+
+	ctx = logging.With(ctx, "session_id", sessionID, "phrase_id", phraseID)
+
+	// Only rate phrases of the user's own sessions that they may review
+	if _, err := app.findUserSession(user, uint(sessionID)); err != nil {
+		slog.WarnContext(ctx, "Session not found", "error", err)
+		return
+	}
+	if _, err := app.findUserPhrase(user, uint(phraseID)); err != nil {
+		slog.WarnContext(ctx, "Phrase not found", "error", err)
 		return
 	}
 
 	// Process the review
-	err = app.handleReview(user, uint(sessionID), uint(phraseID), recallQuality)
+	reviewed, err := app.handleReview(user, uint(sessionID), uint(phraseID), recallQuality)
 	if err != nil {
-		log.Printf("Failed to handle review: %v", err)
+		slog.ErrorContext(ctx, "Failed to handle review", "error", err)
 		return
 	}
 
@@ -162,53 +289,122 @@ func (app *App) handleCallbackQuery(callbackQuery *tgbotapi.CallbackQuery) {
 	editMarkup := tgbotapi.NewEditMessageReplyMarkup(
 		callbackQuery.Message.Chat.ID,
 		callbackQuery.Message.MessageID,
-		tgbotapi.InlineKeyboardMarkup{
-			InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
-		},
+		emptyKeyboard(),
 	)
 	if _, err := app.TelegramBot.Send(editMarkup); err != nil {
-		log.Printf("Failed to remove inline keyboard: %v", err)
+		slog.ErrorContext(ctx, "Failed to remove inline keyboard", "error", err)
 	}
+	app.markKeyboardRemoved(ctx, callbackQuery.Message)
 
 	// Send callback response to the user
-	callback := tgbotapi.NewCallback(callbackQuery.ID, "Review successfully saved!")
+	callback := tgbotapi.NewCallback(callbackQuery.ID, printer(user).Sprintf("Review successfully saved!"))
 	if _, err := app.TelegramBot.Request(callback); err != nil {
-		log.Printf("Failed to send callback response: %v", err)
+		slog.ErrorContext(ctx, "Failed to send callback response", "error", err)
+	}
+
+	if !reviewed {
+		return
+	}
+
+	// Send the next phrase or the summary queued with the review
+	if err := app.DispatchOutbox(ctx); err != nil {
+		slog.ErrorContext(ctx, "Dispatching the outbox failed", "error", err)
 	}
 }
 
-func (app *App) handleReview(
-	user *models.User,
-	sessionID uint,
-	phraseID uint,
-	recallQuality models.RecallQuality,
-) error {
-	review, err := app.ReviewPhrase(
-		phraseID,
-		user.ID,
-		sessionID,
-		recallQuality,
-	)
+// handleShowAnswerCallback reveals the text of a phrase sent with its answer
+// hidden and swaps the button for the rating keyboard.
+func (app *App) handleShowAnswerCallback(ctx context.Context, callbackQuery *tgbotapi.CallbackQuery, data []string) {
+	if len(data) != 3 {
+		slog.WarnContext(ctx, "Invalid callback data", "data", callbackQuery.Data)
+		return
+	}
 
+	sessionID, err := strconv.Atoi(data[1])
 	if err != nil {
-		log.Printf("Failed to review the phrase: %v", err)
-		return err
+		slog.ErrorContext(ctx, "Invalid session ID", "error", err)
+		return
 	}
-	if review == nil {
-		return nil
+
+	phraseID, err := strconv.Atoi(data[2])
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid phrase ID", "error", err)
+		return
 	}
 
-	reviewedPhrasesCount, err := app.DB.CountReviewedPhrasesInSession(sessionID)
+	user, err := app.DB.FindUserByTelegramID(callbackQuery.From.ID)
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+
+	// Only reveal phrases of the user's own sessions that they may review
+	if _, err := app.findUserSession(user, uint(sessionID)); err != nil {
+		slog.WarnContext(ctx, "Session not found", "session_id", sessionID, "error", err)
+		return
+	}
+
+	phrase, err := app.findUserPhrase(user, uint(phraseID))
 	if err != nil {
-		return err
+		slog.WarnContext(ctx, "Phrase not found", "phrase_id", phraseID, "error", err)
+		return
 	}
 
-	if reviewedPhrasesCount >= app.Config.SessionSize {
-		err = app.endSession(sessionID)
-		if err != nil {
+	answer := removeHashtags(phrase.Text)
+	markup := reviewKeyboard(uint(sessionID), phrase.ID)
+	message := callbackQuery.Message
+
+	var edit tgbotapi.Chattable
+	callback := tgbotapi.NewCallback(callbackQuery.ID, "")
+	if phrase.MediaType == models.MediaTypePhoto {
+		editCaption := tgbotapi.NewEditMessageCaption(message.Chat.ID, message.MessageID, answer)
+		editCaption.ReplyMarkup = &markup
+		edit = editCaption
+	} else {
+		// Stickers have no caption to reveal, so the answer is shown in an alert
+		edit = tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, message.MessageID, markup)
+		callback = tgbotapi.NewCallbackWithAlert(callbackQuery.ID, answer)
+	}
+
+	if _, err := app.TelegramBot.Send(edit); err != nil {
+		slog.ErrorContext(ctx, "Failed to reveal the answer", "error", err)
+	}
+
+	if _, err := app.TelegramBot.Request(callback); err != nil {
+		slog.ErrorContext(ctx, "Failed to send callback response", "error", err)
+	}
+}
+
+// handleReview stores the review and queues what comes next in the session
+// in one transaction, so a failure leaves neither behind. It reports whether
+// the phrase was reviewed, the caller then dispatches the outbox.
+func (app *App) handleReview(
+	user *models.User,
+	sessionID uint,
+	phraseID uint,
+	recallQuality models.RecallQuality,
+) (bool, error) {
+	reviewed := false
+	err := app.DB.Transaction(func(tx database.DatabaseClient) error {
+		review, err := app.reviewPhrase(
+			tx,
+			phraseID,
+			user.ID,
+			sessionID,
+			recallQuality,
+		)
+		if err != nil || review == nil {
 			return err
 		}
+		reviewed = true
+
+		// Keep the session going with the next phrase or wrap it up with a summary
+		return app.advanceSession(tx, user, sessionID)
+	})
+	if err != nil || !reviewed {
+		return false, err
 	}
+	metrics.ObserveReview(uint8(recallQuality))
 
-	return nil
+	return true, nil
 }
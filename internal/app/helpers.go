@@ -1,6 +1,14 @@
 package app
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/i18n"
+	"github.com/kiasaty/phrase-mate/models"
+	"golang.org/x/text/message"
+)
 
 func extractHashtags(text string) []string {
 	words := strings.Fields(text)
@@ -23,3 +31,49 @@ func removeHashtags(text string) string {
 	}
 	return strings.Join(filtered, " ")
 }
+
+// splitPhraseSides splits a two-sided phrase written as "front = back", or
+// with the front and the back on separate lines, dropping its hashtags.
+func splitPhraseSides(text string) (string, string, bool) {
+	for _, separator := range []string{" = ", "\n"} {
+		front, back, found := strings.Cut(text, separator)
+		if !found {
+			continue
+		}
+
+		front, back = removeHashtags(front), removeHashtags(back)
+		if front != "" && back != "" {
+			return front, back, true
+		}
+	}
+
+	return "", "", false
+}
+
+func extractMedia(message *tgbotapi.Message) (models.MediaType, string) {
+	switch {
+	case message.Voice != nil:
+		return models.MediaTypeVoice, message.Voice.FileID
+	case message.Audio != nil:
+		return models.MediaTypeAudio, message.Audio.FileID
+	case len(message.Photo) > 0:
+		// Telegram lists the photo sizes from the smallest to the largest
+		return models.MediaTypePhoto, message.Photo[len(message.Photo)-1].FileID
+	case message.ReplyToMessage != nil && message.ReplyToMessage.Sticker != nil:
+		// Stickers can't carry a caption, so they are saved by replying to them with the text
+		return models.MediaTypeSticker, message.ReplyToMessage.Sticker.FileID
+	default:
+		return models.MediaTypeNone, ""
+	}
+}
+
+// printer returns the printer of the bot messages in the user's language.
+func printer(user *models.User) *message.Printer {
+	return i18n.Printer(user.PreferredLanguage())
+}
+
+// formatID formats an ID with ASCII digits in every language, so it can be
+// typed back into commands like /phrase.
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractMedia(t *testing.T) {
+	mediaType, fileID := extractMedia(&tgbotapi.Message{Voice: &tgbotapi.Voice{FileID: "voice-id"}})
+	assert.Equal(t, models.MediaTypeVoice, mediaType)
+	assert.Equal(t, "voice-id", fileID)
+
+	mediaType, fileID = extractMedia(&tgbotapi.Message{Audio: &tgbotapi.Audio{FileID: "audio-id"}})
+	assert.Equal(t, models.MediaTypeAudio, mediaType)
+	assert.Equal(t, "audio-id", fileID)
+
+	mediaType, fileID = extractMedia(&tgbotapi.Message{
+		Caption: "Speisekarte #german",
+		Photo:   []tgbotapi.PhotoSize{{FileID: "small-id"}, {FileID: "large-id"}},
+	})
+	assert.Equal(t, models.MediaTypePhoto, mediaType)
+	assert.Equal(t, "large-id", fileID)
+
+	mediaType, fileID = extractMedia(&tgbotapi.Message{
+		Text:           "Apfel #german",
+		ReplyToMessage: &tgbotapi.Message{Sticker: &tgbotapi.Sticker{FileID: "sticker-id"}},
+	})
+	assert.Equal(t, models.MediaTypeSticker, mediaType)
+	assert.Equal(t, "sticker-id", fileID)
+
+	mediaType, fileID = extractMedia(&tgbotapi.Message{Text: "plain #text"})
+	assert.Equal(t, models.MediaTypeNone, mediaType)
+	assert.Equal(t, "", fileID)
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+// trackSentPhrase records the message the phrase went out as and removes the
+// keyboards of the earlier messages of the phrase, so only the newest prompt
+// can be answered.
+func (app *App) trackSentPhrase(ctx context.Context, user *models.User, sessionID uint, phrase *models.Phrase, messageID int) {
+	sentMessage := &models.SentMessage{
+		UserID:      user.ID,
+		ChatID:      user.TelegramChatID,
+		MessageID:   messageID,
+		SessionID:   sessionID,
+		PhraseID:    phrase.ID,
+		HasKeyboard: !asksTypedAnswer(user, phrase),
+	}
+	if err := app.DB.CreateSentMessage(sentMessage); err != nil {
+		slog.ErrorContext(ctx, "Recording the sent phrase failed", "error", err)
+		return
+	}
+
+	messages, err := app.DB.FindPhraseMessagesWithKeyboard(user.TelegramChatID, phrase.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Finding the earlier prompts of the phrase failed", "error", err)
+		return
+	}
+
+	var earlierMessages []*models.SentMessage
+	for _, message := range messages {
+		if message.ID != sentMessage.ID {
+			earlierMessages = append(earlierMessages, message)
+		}
+	}
+
+	if err := app.removeKeyboards(ctx, earlierMessages); err != nil {
+		slog.ErrorContext(ctx, "Removing the keyboards of the earlier prompts failed", "error", err)
+	}
+}
+
+// expireSessionKeyboards removes the keyboards of the phrases sent in the
+// session.
+func (app *App) expireSessionKeyboards(ctx context.Context, sessionID uint) error {
+	messages, err := app.DB.FindSessionMessagesWithKeyboard(sessionID)
+	if err != nil {
+		return err
+	}
+
+	return app.removeKeyboards(ctx, messages)
+}
+
+// removeKeyboards edits the keyboards away from the messages. Messages that
+// Telegram refuses to edit, e.g. because they were deleted, are given up on.
+func (app *App) removeKeyboards(ctx context.Context, messages []*models.SentMessage) error {
+	for _, message := range messages {
+		edit := tgbotapi.NewEditMessageReplyMarkup(message.ChatID, message.MessageID, emptyKeyboard())
+		if _, err := app.TelegramBot.Request(edit); err != nil {
+			var apiErr *tgbotapi.Error
+			if !errors.As(err, &apiErr) || apiErr.Code != http.StatusBadRequest {
+				return err
+			}
+			slog.DebugContext(ctx, "Giving up on removing the keyboard", "message_id", message.MessageID, "error", err)
+		}
+
+		if err := app.DB.MarkSentMessageKeyboardRemoved(message.ChatID, message.MessageID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markKeyboardRemoved records that the keyboard of an answered message was
+// replaced, so it isn't expired again later.
+func (app *App) markKeyboardRemoved(ctx context.Context, message *tgbotapi.Message) {
+	if err := app.DB.MarkSentMessageKeyboardRemoved(message.Chat.ID, message.MessageID); err != nil {
+		slog.ErrorContext(ctx, "Recording the removed keyboard failed", "error", err)
+	}
+}
+
+func emptyKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func findSentMessages(t *testing.T, app *App) []*models.SentMessage {
+	var messages []*models.SentMessage
+	err := app.DB.(*database.Client).DB.Order("id ASC").Find(&messages).Error
+	assert.NoError(t, err)
+
+	return messages
+}
+
+func TestNewerPromptExpiresEarlierKeyboards(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	ctx := context.Background()
+
+	_, err := setup.app.SendNextPhraseToReviewForUser(ctx, setup.user, false)
+	assert.NoError(t, err)
+	skipRateLimits(setup.app)
+	_, err = setup.app.SendNextPhraseToReviewForUser(ctx, setup.user, false)
+	assert.NoError(t, err)
+
+	messages := findSentMessages(t, setup.app)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, setup.phrase.ID, messages[0].PhraseID)
+	assert.Equal(t, 1, messages[0].MessageID)
+	assert.False(t, messages[0].HasKeyboard)
+	assert.Equal(t, 2, messages[1].MessageID)
+	assert.True(t, messages[1].HasKeyboard)
+
+	edits := telegram.sent("editMessageReplyMarkup")
+	assert.Len(t, edits, 1)
+	assert.Equal(t, "1", edits[0].Params.Get("message_id"))
+}
+
+func TestEndedSessionExpiresKeyboards(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	ctx := context.Background()
+
+	_, err := setup.app.SendNextPhraseToReviewForUser(ctx, setup.user, false)
+	assert.NoError(t, err)
+	session, err := setup.app.EndActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+
+	// The keyboards wait in the outbox until it's dispatched
+	assert.Empty(t, telegram.sent("editMessageReplyMarkup"))
+	assert.NoError(t, setup.app.DispatchOutbox(ctx))
+
+	edits := telegram.sent("editMessageReplyMarkup")
+	assert.Len(t, edits, 1)
+	assert.Equal(t, "123", edits[0].Params.Get("chat_id"))
+	assert.Equal(t, "1", edits[0].Params.Get("message_id"))
+
+	messages := findSentMessages(t, setup.app)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, session.ID, messages[0].SessionID)
+	assert.False(t, messages[0].HasKeyboard)
+}
+
+func TestTypedPromptsHaveNoKeyboard(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	ctx := context.Background()
+
+	assert.NoError(t, setup.app.DB.SetUserReviewMode(setup.user.ID, models.ReviewModeTyped))
+	setup.phrase.Text = "der Hund = the dog"
+	assert.NoError(t, setup.app.DB.UpdatePhrase(setup.phrase))
+
+	_, err := setup.app.SendNextPhraseToReviewForUser(ctx, setup.user, false)
+	assert.NoError(t, err)
+	_, err = setup.app.EndActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, setup.app.DispatchOutbox(ctx))
+
+	messages := findSentMessages(t, setup.app)
+	assert.Len(t, messages, 1)
+	assert.False(t, messages[0].HasKeyboard)
+	assert.Empty(t, telegram.sent("editMessageReplyMarkup"))
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var dueReviewsDesc = prometheus.NewDesc(
+	"phrase_mate_due_reviews",
+	"Number of reviews due now, by user.",
+	[]string{"user_id"},
+	nil,
+)
+
+// dueReviewsCollector counts the due reviews of every user when the metrics
+// are scraped, so the gauge is never stale.
+type dueReviewsCollector struct {
+	app *App
+}
+
+func (c *dueReviewsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dueReviewsDesc
+}
+
+func (c *dueReviewsCollector) Collect(ch chan<- prometheus.Metric) {
+	users, err := c.app.DB.GetAllUsers()
+	if err != nil {
+		slog.Error("Error retrieving users", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		count, err := c.app.DB.CountDueReviews(user.ID, now)
+		if err != nil {
+			slog.Error("Counting due reviews failed", "user_id", user.ID, "error", err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			dueReviewsDesc,
+			prometheus.GaugeValue,
+			float64(count),
+			strconv.Itoa(int(user.ID)),
+		)
+	}
+}
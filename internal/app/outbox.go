@@ -0,0 +1,180 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/internal/telegram"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+const (
+	// outboxRetryDelay is how long a message waits after a failed send before
+	// it's tried again.
+	outboxRetryDelay  = 5 * time.Minute
+	outboxMaxAttempts = 5
+	// outboxLease is how long a dispatcher has for a claimed batch before
+	// another one takes it over, e.g. because the first one stopped. Batches
+	// are kept small so they are sent well within it.
+	outboxLease     = 15 * time.Minute
+	outboxBatchSize = 10
+	// outboxPollInterval is also about how late messages put off for the
+	// rate limits go out
+	outboxPollInterval = 5 * time.Second
+)
+
+// errOutboxMessageObsolete is returned for messages that no longer make sense
+// to send, so they aren't retried.
+var errOutboxMessageObsolete = errors.New("outbox message is obsolete")
+
+// enqueuePhrase puts the phrase in the outbox to be sent for review in the
+// session.
+func enqueuePhrase(db database.DatabaseClient, user *models.User, sessionID uint, phrase *models.Phrase) error {
+	return db.CreateOutboxMessage(&models.OutboxMessage{
+		UserID:    user.ID,
+		ChatID:    user.TelegramChatID,
+		Kind:      models.OutboxKindPhrase,
+		SessionID: sessionID,
+		PhraseID:  phrase.ID,
+	})
+}
+
+// enqueueKeyboardExpiry puts the removal of the keyboards left in the session
+// in the outbox.
+func enqueueKeyboardExpiry(db database.DatabaseClient, session *models.Session) error {
+	return db.CreateOutboxMessage(&models.OutboxMessage{
+		UserID:    session.UserID,
+		Kind:      models.OutboxKindExpireKeyboards,
+		SessionID: session.ID,
+	})
+}
+
+// enqueueText puts the text in the outbox to be sent to the user.
+func enqueueText(db database.DatabaseClient, user *models.User, text string) error {
+	return db.CreateOutboxMessage(&models.OutboxMessage{
+		UserID: user.ID,
+		ChatID: user.TelegramChatID,
+		Kind:   models.OutboxKindText,
+		Text:   text,
+	})
+}
+
+// DispatchOutbox sends the messages waiting in the outbox. Failed messages are
+// left for a later dispatch until they run out of attempts.
+func (app *App) DispatchOutbox(ctx context.Context) error {
+	for {
+		messages, err := app.DB.ClaimOutboxMessages(time.Now().Add(-outboxLease), outboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			app.dispatchOutboxMessage(ctx, message)
+		}
+
+		if len(messages) < outboxBatchSize {
+			return nil
+		}
+	}
+}
+
+// runOutboxWorker closes the idle sessions and sends what's left in the
+// outbox every outboxPollInterval until the process exits.
+func (app *App) runOutboxWorker() {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := app.CloseIdleSessions(); err != nil {
+			slog.Error("Closing the idle sessions failed", "error", err)
+		}
+		if err := app.DispatchOutbox(context.Background()); err != nil {
+			slog.Error("Dispatching the outbox failed", "error", err)
+		}
+	}
+}
+
+func (app *App) dispatchOutboxMessage(ctx context.Context, message *models.OutboxMessage) {
+	ctx = logging.With(ctx, "outbox_message_id", message.ID, "user_id", message.UserID)
+
+	if message.ChatID != 0 {
+		wait, ok := app.limiter.Reserve(message.ChatID, time.Now())
+		if !ok {
+			// Put the message off rather than hold up the other chats
+			if err := app.DB.DeferOutboxMessage(message.ID, time.Now().Add(wait)); err != nil {
+				slog.ErrorContext(ctx, "Deferring the outbox message failed", "error", err)
+			}
+			return
+		}
+		time.Sleep(wait)
+	}
+
+	sentMessageID, err := app.sendOutboxMessage(ctx, message)
+	if err != nil {
+		var forbidden *telegram.ForbiddenError
+		if errors.As(err, &forbidden) && message.ChatID != 0 {
+			app.DeactivateChat(message.ChatID)
+		}
+
+		attempt := message.Attempts + 1
+		final := attempt >= outboxMaxAttempts || errors.Is(err, errOutboxMessageObsolete) || forbidden != nil
+		slog.ErrorContext(ctx, "Sending the outbox message failed", "attempt", attempt, "final", final, "error", err)
+
+		if err := app.DB.MarkOutboxMessageFailed(message.ID, err, final, time.Now().Add(outboxRetryDelay)); err != nil {
+			slog.ErrorContext(ctx, "Recording the failed outbox message failed", "error", err)
+		}
+		return
+	}
+
+	if err := app.DB.MarkOutboxMessageSent(message.ID, sentMessageID); err != nil {
+		slog.ErrorContext(ctx, "Recording the sent outbox message failed", "error", err)
+	}
+}
+
+func (app *App) sendOutboxMessage(ctx context.Context, message *models.OutboxMessage) (int, error) {
+	// Users may have left, been blocked or turned down since it was queued
+	user, err := app.DB.FindUser(message.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if user == nil || !user.CanReview() {
+		return 0, errOutboxMessageObsolete
+	}
+
+	switch message.Kind {
+	case models.OutboxKindText:
+		return app.sendText(message.ChatID, message.Text)
+	case models.OutboxKindPhrase:
+		ctx = logging.With(ctx, "session_id", message.SessionID, "phrase_id", message.PhraseID)
+
+		session, err := app.DB.FindSession(message.SessionID)
+		if err != nil {
+			return 0, err
+		}
+		// The session was closed before the phrase got out
+		if session.EndedAt != nil {
+			return 0, errOutboxMessageObsolete
+		}
+
+		phrase, err := app.DB.FindPhrase(message.PhraseID)
+		if err != nil {
+			return 0, err
+		}
+
+		sentMessageID, err := app.SendPhrase(ctx, user, message.SessionID, phrase)
+		if err != nil {
+			return 0, err
+		}
+		app.trackSentPhrase(ctx, user, message.SessionID, phrase, sentMessageID)
+
+		return sentMessageID, nil
+	case models.OutboxKindExpireKeyboards:
+		return 0, app.expireSessionKeyboards(logging.With(ctx, "session_id", message.SessionID), message.SessionID)
+	default:
+		return 0, errOutboxMessageObsolete
+	}
+}
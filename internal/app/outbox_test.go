@@ -0,0 +1,216 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/internal/telegram"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func findOutboxMessages(t *testing.T, app *App) []*models.OutboxMessage {
+	var messages []*models.OutboxMessage
+	err := app.DB.(*database.Client).DB.Order("id ASC").Find(&messages).Error
+	assert.NoError(t, err)
+
+	return messages
+}
+
+// skipRateLimits lets the chats be sent to again right away, as if a while
+// passed since the last messages.
+func skipRateLimits(app *App) {
+	app.limiter = telegram.NewLimiter()
+}
+
+func TestSendNextPhraseToReviewGoesThroughOutbox(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+
+	_, err := setup.app.SendNextPhraseToReviewForUser(context.Background(), setup.user, false)
+	assert.NoError(t, err)
+
+	session, err := setup.app.DB.FindActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+
+	messages := findOutboxMessages(t, setup.app)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, models.OutboxKindPhrase, messages[0].Kind)
+	assert.Equal(t, session.ID, messages[0].SessionID)
+	assert.Equal(t, setup.phrase.ID, messages[0].PhraseID)
+	assert.Equal(t, models.OutboxStatusSent, messages[0].Status)
+	assert.Equal(t, 1, messages[0].SentMessageID)
+	assert.Equal(t, uint(1), messages[0].Attempts)
+	assert.NotNil(t, messages[0].SentAt)
+	assert.Len(t, telegram.sent("sendMessage"), 1)
+}
+
+func TestSendNextPhraseToReviewDryRun(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+
+	plans, err := setup.app.SendNextPhraseToReviewForAllUsers(true)
+	assert.NoError(t, err)
+	assert.Len(t, plans, 1)
+	assert.Equal(t, setup.user.ID, plans[0].User.ID)
+	assert.True(t, plans[0].Plan.StartsSession)
+	assert.Equal(t, setup.phrase.ID, plans[0].Plan.Phrase.ID)
+
+	// Nothing is applied, queued or sent
+	session, err := setup.app.DB.FindActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+	assert.Empty(t, findOutboxMessages(t, setup.app))
+	assert.Empty(t, telegram.sent("sendMessage"))
+}
+
+func TestDispatchOutboxRetriesFailedMessages(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	telegram.down = true
+	ctx := context.Background()
+
+	_, err := setup.app.SendNextPhraseToReviewForUser(ctx, setup.user, false)
+	assert.NoError(t, err)
+
+	// The session and the phrase in the outbox outlive the failed send
+	messages := findOutboxMessages(t, setup.app)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, models.OutboxStatusPending, messages[0].Status)
+	assert.Equal(t, uint(1), messages[0].Attempts)
+	assert.Equal(t, "telegram is down", messages[0].LastError)
+
+	// Failed messages wait before they are retried
+	assert.NoError(t, setup.app.DispatchOutbox(ctx))
+	assert.Len(t, telegram.sent("sendMessage"), 1)
+
+	telegram.down = false
+	setup.app.DB.(*database.Client).DB.Model(messages[0]).Update("available_at", time.Now())
+	skipRateLimits(setup.app)
+
+	assert.NoError(t, setup.app.DispatchOutbox(ctx))
+	assert.Len(t, telegram.sent("sendMessage"), 2)
+
+	messages = findOutboxMessages(t, setup.app)
+	assert.Equal(t, models.OutboxStatusSent, messages[0].Status)
+	assert.Equal(t, uint(2), messages[0].Attempts)
+}
+
+func TestDispatchOutboxDropsPhrasesOfEndedSessions(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	telegram.down = true
+	ctx := context.Background()
+
+	_, err := setup.app.SendNextPhraseToReviewForUser(ctx, setup.user, false)
+	assert.NoError(t, err)
+	_, err = setup.app.EndActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+
+	telegram.down = false
+	setup.app.DB.(*database.Client).DB.Model(&models.OutboxMessage{}).Where("1 = 1").Update("available_at", time.Now())
+	skipRateLimits(setup.app)
+
+	assert.NoError(t, setup.app.DispatchOutbox(ctx))
+
+	messages := findOutboxMessages(t, setup.app)
+	assert.Equal(t, models.OutboxStatusFailed, messages[0].Status)
+	assert.Equal(t, errOutboxMessageObsolete.Error(), messages[0].LastError)
+	assert.Len(t, telegram.sent("sendMessage"), 1)
+}
+
+func TestClaimOutboxMessagesClaimsOnce(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	assert.NoError(t, enqueueText(db, setup.user, "Hello"))
+
+	claimed, err := db.ClaimOutboxMessages(time.Now().Add(-outboxLease), 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, models.OutboxStatusSending, claimed[0].Status)
+
+	claimed, err = db.ClaimOutboxMessages(time.Now().Add(-outboxLease), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// A dispatcher that stopped halfway gets taken over once the claim is stale
+	claimed, err = db.ClaimOutboxMessages(time.Now().Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	// Failed messages wait for their retry however stale their claim
+	err = db.MarkOutboxMessageFailed(claimed[0].ID, errors.New("failed"), false, time.Now().Add(outboxRetryDelay))
+	assert.NoError(t, err)
+	claimed, err = db.ClaimOutboxMessages(time.Now().Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestDispatchOutboxPutsOffBusyChats(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+	ctx := context.Background()
+
+	other, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+
+	assert.NoError(t, enqueueText(db, setup.user, "First"))
+	assert.NoError(t, enqueueText(db, setup.user, "Second"))
+	assert.NoError(t, enqueueText(db, other, "Other"))
+	assert.NoError(t, setup.app.DispatchOutbox(ctx))
+
+	// The second message to the chat waits without holding up the other chat
+	sent := telegram.sent("sendMessage")
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "First", sent[0].Params.Get("text"))
+	assert.Equal(t, "Other", sent[1].Params.Get("text"))
+
+	messages := findOutboxMessages(t, setup.app)
+	assert.Equal(t, models.OutboxStatusPending, messages[1].Status)
+	assert.Equal(t, uint(0), messages[1].Attempts)
+	assert.NotNil(t, messages[1].AvailableAt)
+
+	// It's sent once its time comes
+	db.(*database.Client).DB.Model(messages[1]).Update("available_at", time.Now())
+	skipRateLimits(setup.app)
+	assert.NoError(t, setup.app.DispatchOutbox(ctx))
+	assert.Len(t, telegram.sent("sendMessage"), 3)
+}
+
+func TestDispatchOutboxDeactivatesForbiddenChats(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	telegram.forbidden = true
+	db := setup.app.DB
+
+	assert.NoError(t, enqueueText(db, setup.user, "Hallo"))
+	assert.NoError(t, setup.app.DispatchOutbox(context.Background()))
+
+	messages := findOutboxMessages(t, setup.app)
+	assert.Equal(t, models.OutboxStatusFailed, messages[0].Status)
+
+	user, err := db.FindUser(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.UserStatusInactive, user.Status)
+}
+
+func TestDispatchOutboxDropsMessagesOfInactiveUsers(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+
+	assert.NoError(t, enqueueText(db, setup.user, "Hallo"))
+	assert.NoError(t, db.SetUserStatus(setup.user.ID, models.UserStatusInactive))
+	assert.NoError(t, setup.app.DispatchOutbox(context.Background()))
+
+	messages := findOutboxMessages(t, setup.app)
+	assert.Equal(t, models.OutboxStatusFailed, messages[0].Status)
+	assert.Equal(t, errOutboxMessageObsolete.Error(), messages[0].LastError)
+	assert.Empty(t, telegram.sent("sendMessage"))
+}
@@ -0,0 +1,321 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/models"
+	"golang.org/x/text/message"
+)
+
+const phraseHistoryLimit = 5
+
+const (
+	phraseActionDelete    = "delete"
+	phraseActionSuspend   = "suspend"
+	phraseActionUnsuspend = "unsuspend"
+	phraseActionUnmaster  = "unmaster"
+	phraseActionReset     = "reset"
+	phraseActionTag       = "tag"
+)
+
+func (app *App) handlePhraseCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	p := printer(user)
+	phraseID, err := strconv.ParseUint(strings.TrimSpace(message.CommandArguments()), 10, 64)
+	if err != nil {
+		if err := app.SendText(message.Chat.ID, p.Sprintf("Usage: /phrase <id>")); err != nil {
+			slog.ErrorContext(ctx, "Sending the phrase usage failed", "error", err)
+		}
+		return
+	}
+
+	phrase, err := app.findUserPhrase(user, uint(phraseID))
+	if err != nil {
+		if err := app.SendText(message.Chat.ID, p.Sprintf("Phrase not found.")); err != nil {
+			slog.ErrorContext(ctx, "Sending the phrase failed", "error", err)
+		}
+		return
+	}
+
+	text, markup, err := app.renderPhraseCard(user, phrase)
+	if err != nil {
+		slog.ErrorContext(ctx, "Rendering the phrase failed", "error", err)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyMarkup = markup
+	if _, err := app.TelegramBot.Send(msg); err != nil {
+		slog.ErrorContext(ctx, "Sending the phrase failed", "error", err)
+	}
+}
+
+func (app *App) handlePhraseActionCallback(ctx context.Context, callbackQuery *tgbotapi.CallbackQuery, data []string) {
+	if len(data) != 3 {
+		slog.WarnContext(ctx, "Invalid callback data", "data", callbackQuery.Data)
+		return
+	}
+
+	phraseID, err := strconv.Atoi(data[1])
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid phrase ID", "error", err)
+		return
+	}
+
+	ctx = logging.With(ctx, "phrase_id", phraseID)
+
+	user, err := app.DB.FindUserByTelegramID(callbackQuery.From.ID)
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+
+	phrase, err := app.findUserPhrase(user, uint(phraseID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Phrase not found", "error", err)
+		return
+	}
+
+	action := data[2]
+	if action == phraseActionTag {
+		app.askForTagName(ctx, user, phrase, callbackQuery)
+		return
+	}
+
+	if err := app.applyPhraseAction(user, phrase, action); err != nil {
+		slog.ErrorContext(ctx, "Failed to apply the phrase action", "action", action, "error", err)
+		return
+	}
+
+	p := printer(user)
+	message := callbackQuery.Message
+	if action == phraseActionDelete {
+		edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, p.Sprintf("Phrase deleted."))
+		if _, err := app.TelegramBot.Send(edit); err != nil {
+			slog.ErrorContext(ctx, "Failed to update the phrase message", "error", err)
+		}
+	} else {
+		phrase, err = app.findUserPhrase(user, phrase.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Phrase not found", "error", err)
+			return
+		}
+
+		text, markup, err := app.renderPhraseCard(user, phrase)
+		if err != nil {
+			slog.ErrorContext(ctx, "Rendering the phrase failed", "error", err)
+			return
+		}
+
+		edit := tgbotapi.NewEditMessageTextAndMarkup(message.Chat.ID, message.MessageID, text, markup)
+		if _, err := app.TelegramBot.Send(edit); err != nil {
+			slog.ErrorContext(ctx, "Failed to update the phrase message", "error", err)
+		}
+	}
+
+	if _, err := app.TelegramBot.Request(tgbotapi.NewCallback(callbackQuery.ID, p.Sprintf("Phrase updated!"))); err != nil {
+		slog.ErrorContext(ctx, "Failed to send callback response", "error", err)
+	}
+}
+
+func (app *App) applyPhraseAction(user *models.User, phrase *models.Phrase, action string) error {
+	// Resetting only touches the user's own schedule, everything else edits the phrase itself
+	if action != phraseActionReset && !canEditPhrase(user, phrase) {
+		return errors.New("only the owner can edit the phrase")
+	}
+
+	switch action {
+	case phraseActionDelete:
+		return app.DB.DeletePhrase(phrase.ID)
+	case phraseActionSuspend:
+		return app.DB.SetPhraseSuspended(phrase.ID, true)
+	case phraseActionUnsuspend:
+		return app.DB.SetPhraseSuspended(phrase.ID, false)
+	case phraseActionUnmaster:
+		return app.DB.UnmarkPhraseAsMastered(phrase.ID)
+	case phraseActionReset:
+		return app.DB.DeleteReview(user.ID, phrase.ID)
+	default:
+		return errors.New("unknown phrase action: " + action)
+	}
+}
+
+// askForTagName waits for the user's next message to add it as a tag to the phrase.
+func (app *App) askForTagName(ctx context.Context, user *models.User, phrase *models.Phrase, callbackQuery *tgbotapi.CallbackQuery) {
+	if !canEditPhrase(user, phrase) {
+		slog.WarnContext(ctx, "User can't tag the phrase", "user_id", user.ID)
+		return
+	}
+
+	chatID := callbackQuery.Message.Chat.ID
+	err := app.startConversation(chatID, user, models.ConversationAwaitingTagName, 0, phrase.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to start the conversation", "error", err)
+		return
+	}
+
+	if err := app.SendText(chatID, printer(user).Sprintf("Send the tag to add to the phrase, e.g. #german, or /cancel.")); err != nil {
+		slog.ErrorContext(ctx, "Sending the tag prompt failed", "error", err)
+	}
+
+	if _, err := app.TelegramBot.Request(tgbotapi.NewCallback(callbackQuery.ID, "")); err != nil {
+		slog.ErrorContext(ctx, "Failed to send callback response", "error", err)
+	}
+}
+
+func (app *App) handleTagNameReply(ctx context.Context, message *tgbotapi.Message, conversation *models.Conversation) {
+	ctx = logging.With(ctx, "phrase_id", conversation.PhraseID)
+
+	user, err := app.DB.FindUserByTelegramID(message.From.ID)
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+
+	phrase, err := app.findUserPhrase(user, conversation.PhraseID)
+	if err != nil || !canEditPhrase(user, phrase) {
+		slog.ErrorContext(ctx, "Phrase not found", "error", err)
+		return
+	}
+
+	// Accept the tag with or without the leading #
+	var hashtags []string
+	for _, word := range strings.Fields(message.Text) {
+		hashtags = append(hashtags, "#"+strings.TrimPrefix(word, "#"))
+	}
+	if len(hashtags) == 0 {
+		return
+	}
+
+	if err := app.addPhraseTags(phrase, hashtags); err != nil {
+		slog.ErrorContext(ctx, "Error adding tags to phrase", "error", err)
+		return
+	}
+
+	reply := printer(user).Sprintf("Added %s to phrase %s.", strings.Join(hashtags, " "), formatID(phrase.ID))
+	if err := app.SendText(message.Chat.ID, reply); err != nil {
+		slog.ErrorContext(ctx, "Sending the tag confirmation failed", "error", err)
+	}
+}
+
+// findUserPhrase finds a phrase and makes sure it belongs to the given user
+// or is shared with them through a deck.
+func (app *App) findUserPhrase(user *models.User, phraseID uint) (*models.Phrase, error) {
+	phrase, err := app.DB.FindPhrase(phraseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if phrase.UserID == user.ID {
+		return phrase, nil
+	}
+
+	if phrase.DeckID != nil {
+		isMember, err := app.DB.IsDeckMember(*phrase.DeckID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if isMember {
+			return phrase, nil
+		}
+	}
+
+	return nil, errors.New("phrase does not belong to the user")
+}
+
+// canEditPhrase reports whether the user owns the phrase, deck members can
+// only review shared phrases.
+func canEditPhrase(user *models.User, phrase *models.Phrase) bool {
+	return phrase.UserID == user.ID
+}
+
+func (app *App) renderPhraseCard(user *models.User, phrase *models.Phrase) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	review, err := app.DB.FindReview(user.ID, phrase.ID)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	history, err := app.GetReviewHistory(user.ID, phrase.ID)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	p := printer(user)
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s\n\n%s\n", p.Sprintf("Phrase %s", formatID(phrase.ID)), formatPhraseWithTags(phrase))
+
+	switch {
+	case phrase.IsMastered:
+		builder.WriteString("\n" + p.Sprintf("Status: mastered"))
+	case phrase.IsSuspended:
+		builder.WriteString("\n" + p.Sprintf("Status: suspended"))
+	default:
+		builder.WriteString("\n" + p.Sprintf("Status: active"))
+	}
+
+	if review != nil && review.NextReviewAt != nil {
+		builder.WriteString("\n" + p.Sprintf("Next review: %s", review.NextReviewAt.Format("2006-01-02")))
+	} else {
+		builder.WriteString("\n" + p.Sprintf("Next review: not reviewed yet"))
+	}
+
+	if len(history) > 0 {
+		builder.WriteString("\n\n" + p.Sprintf("History:"))
+		for i, entry := range history {
+			if i == phraseHistoryLimit {
+				break
+			}
+			if entry.ReviewedAt == nil {
+				continue
+			}
+			builder.WriteString("\n" + p.Sprintf(
+				"%s · quality %d · interval %d days",
+				entry.ReviewedAt.Format("2006-01-02"),
+				entry.RecallQuality,
+				entry.Interval,
+			))
+		}
+	}
+
+	return builder.String(), phraseActionsMarkup(p, phrase, canEditPhrase(user, phrase)), nil
+}
+
+func phraseActionsMarkup(p *message.Printer, phrase *models.Phrase, canEdit bool) tgbotapi.InlineKeyboardMarkup {
+	callbackPrefix := "phrase:" + strconv.Itoa(int(phrase.ID)) + ":"
+
+	if !canEdit {
+		return tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Reset scheduling"), callbackPrefix+phraseActionReset),
+			),
+		)
+	}
+
+	suspendButton := tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Suspend"), callbackPrefix+phraseActionSuspend)
+	if phrase.IsSuspended {
+		suspendButton = tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Unsuspend"), callbackPrefix+phraseActionUnsuspend)
+	}
+
+	firstRow := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Add tag"), callbackPrefix+phraseActionTag),
+		suspendButton,
+	}
+	if phrase.IsMastered {
+		firstRow = append(firstRow, tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Unmaster"), callbackPrefix+phraseActionUnmaster))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		firstRow,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Reset scheduling"), callbackPrefix+phraseActionReset),
+			tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Delete"), callbackPrefix+phraseActionDelete),
+		),
+	)
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuspendedPhraseIsNotReviewed(t *testing.T) {
+	setup := setupTestReview(t)
+	session := &models.Session{UserID: setup.user.ID}
+
+	assert.NoError(t, setup.app.applyPhraseAction(setup.user, setup.phrase, phraseActionSuspend))
+
+	phrase, err := setup.app.getNextPhraseToReview(session)
+	assert.NoError(t, err)
+	assert.Nil(t, phrase)
+
+	assert.NoError(t, setup.app.applyPhraseAction(setup.user, setup.phrase, phraseActionUnsuspend))
+
+	phrase, err = setup.app.getNextPhraseToReview(session)
+	assert.NoError(t, err)
+	assert.NotNil(t, phrase)
+}
+
+func TestDeletedPhraseIsNotReviewed(t *testing.T) {
+	setup := setupTestReview(t)
+	session := &models.Session{UserID: setup.user.ID}
+
+	_, err := setup.app.ReviewPhrase(setup.phrase.ID, setup.user.ID, 1, models.QualityPerfect)
+	assert.NoError(t, err)
+
+	// Make the phrase due again
+	review, err := setup.app.DB.FindReview(setup.user.ID, setup.phrase.ID)
+	assert.NoError(t, err)
+	pastTime := time.Now().AddDate(0, 0, -1)
+	review.NextReviewAt = &pastTime
+	assert.NoError(t, setup.app.DB.UpdateReview(review))
+
+	assert.NoError(t, setup.app.applyPhraseAction(setup.user, setup.phrase, phraseActionDelete))
+
+	phrase, err := setup.app.getNextPhraseToReview(session)
+	assert.NoError(t, err)
+	assert.Nil(t, phrase)
+
+	_, err = setup.app.findUserPhrase(setup.user, setup.phrase.ID)
+	assert.Error(t, err)
+}
+
+func TestResetPhraseScheduling(t *testing.T) {
+	setup := setupTestReview(t)
+	session := &models.Session{UserID: setup.user.ID}
+
+	_, err := setup.app.ReviewPhrase(setup.phrase.ID, setup.user.ID, 1, models.QualityPerfect)
+	assert.NoError(t, err)
+
+	phrase, err := setup.app.getNextPhraseToReview(session)
+	assert.NoError(t, err)
+	assert.Nil(t, phrase)
+
+	assert.NoError(t, setup.app.applyPhraseAction(setup.user, setup.phrase, phraseActionReset))
+
+	phrase, err = setup.app.getNextPhraseToReview(session)
+	assert.NoError(t, err)
+	assert.NotNil(t, phrase)
+
+	history, err := setup.app.GetReviewHistory(setup.user.ID, setup.phrase.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestAddPhraseTags(t *testing.T) {
+	setup := setupTestReview(t)
+
+	assert.NoError(t, setup.app.addPhraseTags(setup.phrase, []string{"#German"}))
+
+	phrase := setup.app.DB.FindPhraseByMessageId(setup.phrase.TelegramChatID, setup.phrase.TelegramMessageID)
+	assert.NoError(t, setup.app.addPhraseTags(phrase, []string{"#german", "#food"}))
+
+	phrase = setup.app.DB.FindPhraseByMessageId(setup.phrase.TelegramChatID, setup.phrase.TelegramMessageID)
+	assert.Len(t, phrase.Tags, 2)
+	assert.Equal(t, "#german", phrase.Tags[0].Name)
+	assert.Equal(t, "#food", phrase.Tags[1].Name)
+}
+
+func TestPhrasesAreKeyedByChatAndMessage(t *testing.T) {
+	setup := setupTestReview(t)
+	ctx := context.Background()
+
+	// A group message with the same ID as the user's saved private phrase
+	group := newPrivateMessageUpdate(setup.user.TelegramChatID, "Guten Abend #german").Message
+	group.MessageID = setup.phrase.TelegramMessageID
+	group.Chat = &tgbotapi.Chat{ID: -100, Type: "group", Title: "Team"}
+	setup.app.handleNewPhrase(ctx, group)
+
+	phrase := setup.app.DB.FindPhraseByMessageId(-100, setup.phrase.TelegramMessageID)
+	assert.NotNil(t, phrase)
+	assert.Equal(t, "Guten Abend #german", phrase.Text)
+
+	phrase = setup.app.DB.FindPhraseByMessageId(setup.user.TelegramChatID, setup.phrase.TelegramMessageID)
+	assert.Equal(t, setup.phrase.ID, phrase.ID)
+}
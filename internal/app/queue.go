@@ -0,0 +1,47 @@
+package app
+
+import (
+	"time"
+
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+// UserQueue is what a user has lined up for review.
+type UserQueue struct {
+	ActiveSession *models.Session
+	DueCount      uint
+	DueReviews    []*models.Review
+	NewPhraseIDs  []uint
+}
+
+// GetUserQueue returns the active session of the user along with the next
+// due reviews and new phrases, up to limit of each.
+func (app *App) GetUserQueue(userID uint, limit int) (*UserQueue, error) {
+	activeSession, err := app.findActiveSession(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	dueCount, err := app.DB.CountDueReviews(userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	dueReviews, err := app.DB.FindDueReviews(userID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	newPhraseIDs, err := app.DB.FindNewPhrasesToReview(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserQueue{
+		ActiveSession: activeSession,
+		DueCount:      dueCount,
+		DueReviews:    dueReviews,
+		NewPhraseIDs:  newPhraseIDs,
+	}, nil
+}
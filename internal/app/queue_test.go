@@ -0,0 +1,35 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserQueue(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	queue, err := setup.app.GetUserQueue(setup.user.ID, 10)
+	assert.NoError(t, err)
+	assert.Nil(t, queue.ActiveSession)
+	assert.Equal(t, uint(0), queue.DueCount)
+	assert.Equal(t, []uint{setup.phrase.ID}, queue.NewPhraseIDs)
+
+	review, err := setup.app.ReviewPhrase(setup.phrase.ID, setup.user.ID, 1, models.QualityForgot)
+	assert.NoError(t, err)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	review.NextReviewAt = &yesterday
+	assert.NoError(t, db.UpdateReview(review))
+	session, err := db.CreateSession(&models.Session{UserID: setup.user.ID})
+	assert.NoError(t, err)
+
+	queue, err = setup.app.GetUserQueue(setup.user.ID, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, queue.ActiveSession.ID)
+	assert.Empty(t, queue.NewPhraseIDs)
+	assert.Equal(t, uint(1), queue.DueCount)
+	assert.Equal(t, setup.phrase.ID, queue.DueReviews[0].PhraseID)
+}
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+const (
+	quizOptionsCount = 4
+	// quizCandidatesLimit is how many related phrases are fetched to find
+	// enough distinct distractors among them.
+	quizCandidatesLimit = 20
+)
+
+// Picking the right answer among a few options is easier than recalling it,
+// so a correct answer doesn't count as a perfect recall.
+const (
+	quizCorrectQuality = models.QualityFluent
+	quizWrongQuality   = models.QualityForgot
+)
+
+type quiz struct {
+	Question      string
+	Answer        string
+	Options       []string
+	CorrectOption int
+}
+
+// buildQuiz prepares a multiple choice question for a two-sided phrase, taking
+// the distractors from the user's phrases sharing a tag with it. It returns
+// nil when the phrase can't be asked as a quiz.
+func (app *App) buildQuiz(user *models.User, phrase *models.Phrase) (*quiz, error) {
+	front, back, ok := splitPhraseSides(phrase.Text)
+	if !ok {
+		return nil, nil
+	}
+
+	relatedPhrases, err := app.DB.FindRelatedPhrases(user.ID, phrase.ID, quizCandidatesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var distractors []string
+	for _, relatedPhrase := range relatedPhrases {
+		_, relatedBack, ok := splitPhraseSides(relatedPhrase.Text)
+		if ok {
+			distractors = append(distractors, relatedBack)
+		}
+	}
+
+	return newQuiz(front, back, distractors), nil
+}
+
+// newQuiz shuffles the answer among distinct distractors, or returns nil when
+// there aren't enough of them.
+func newQuiz(question string, answer string, distractors []string) *quiz {
+	options := []string{answer}
+	seen := map[string]bool{answer: true}
+
+	for _, distractor := range distractors {
+		if len(options) == quizOptionsCount {
+			break
+		}
+		if !seen[distractor] {
+			seen[distractor] = true
+			options = append(options, distractor)
+		}
+	}
+
+	if len(options) < quizOptionsCount {
+		return nil
+	}
+
+	rand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+
+	correctOption := 0
+	for i, option := range options {
+		if option == answer {
+			correctOption = i
+		}
+	}
+
+	return &quiz{
+		Question:      question,
+		Answer:        answer,
+		Options:       options,
+		CorrectOption: correctOption,
+	}
+}
+
+func (app *App) sendQuiz(user *models.User, sessionID uint, phrase *models.Phrase, quiz *quiz) (int, error) {
+	buttonKeyPrefix := "quiz:" + strconv.Itoa(int(sessionID)) + ":" + strconv.Itoa(int(phrase.ID))
+
+	// The buttons only carry the option's index, the answer is checked against
+	// the phrase once one is picked
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, option := range quiz.Options {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(option, buttonKeyPrefix+":"+strconv.Itoa(i)),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(user.TelegramChatID, quiz.Question+"\n\n"+printer(user).Sprintf("Choose the right answer:"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	sentMessage, err := app.TelegramBot.Send(msg)
+	return sentMessage.MessageID, err
+}
+
+func (app *App) handleQuizCallback(ctx context.Context, callbackQuery *tgbotapi.CallbackQuery, data []string) {
+	if len(data) != 4 {
+		slog.WarnContext(ctx, "Invalid callback data", "data", callbackQuery.Data)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(data[1])
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid session ID", "error", err)
+		return
+	}
+
+	phraseID, err := strconv.Atoi(data[2])
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid phrase ID", "error", err)
+		return
+	}
+
+	ctx = logging.With(ctx, "session_id", sessionID, "phrase_id", phraseID)
+
+	user, err := app.DB.FindUserByTelegramID(callbackQuery.From.ID)
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+
+	// Only grade phrases of the user's own sessions that they may review
+	if _, err := app.findUserSession(user, uint(sessionID)); err != nil {
+		slog.WarnContext(ctx, "Session not found", "error", err)
+		return
+	}
+	phrase, err := app.findUserPhrase(user, uint(phraseID))
+	if err != nil {
+		slog.WarnContext(ctx, "Phrase not found", "error", err)
+		return
+	}
+
+	option, ok := quizOption(callbackQuery.Message, data[3])
+	if !ok {
+		slog.WarnContext(ctx, "Invalid quiz option", "option", data[3])
+		return
+	}
+
+	front, back, _ := splitPhraseSides(phrase.Text)
+	correct := option == back
+	recallQuality := quizWrongQuality
+	if correct {
+		recallQuality = quizCorrectQuality
+	}
+
+	reviewed, err := app.handleReview(user, uint(sessionID), phrase.ID, recallQuality)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to handle review", "error", err)
+		return
+	}
+
+	// Replace the options with the result
+	p := printer(user)
+	result := p.Sprintf("✅ Correct: %s", back)
+	if !correct {
+		result = p.Sprintf("❌ Wrong, the answer is: %s", back)
+	}
+	edit := tgbotapi.NewEditMessageText(
+		callbackQuery.Message.Chat.ID,
+		callbackQuery.Message.MessageID,
+		front+"\n\n"+result,
+	)
+	if _, err := app.TelegramBot.Send(edit); err != nil {
+		slog.ErrorContext(ctx, "Failed to show the quiz result", "error", err)
+	}
+	app.markKeyboardRemoved(ctx, callbackQuery.Message)
+
+	if _, err := app.TelegramBot.Request(tgbotapi.NewCallback(callbackQuery.ID, p.Sprintf("Review successfully saved!"))); err != nil {
+		slog.ErrorContext(ctx, "Failed to send callback response", "error", err)
+	}
+
+	if !reviewed {
+		return
+	}
+
+	// Send the next phrase or the summary queued with the review
+	if err := app.DispatchOutbox(ctx); err != nil {
+		slog.ErrorContext(ctx, "Dispatching the outbox failed", "error", err)
+	}
+}
+
+// quizOption returns the text of the option picked by its index on the quiz
+// message's keyboard, as Telegram attached the message to the callback.
+func quizOption(message *tgbotapi.Message, index string) (string, bool) {
+	i, err := strconv.Atoi(index)
+	if err != nil || message == nil || message.ReplyMarkup == nil {
+		return "", false
+	}
+
+	rows := message.ReplyMarkup.InlineKeyboard
+	if i < 0 || i >= len(rows) || len(rows[i]) == 0 {
+		return "", false
+	}
+
+	return rows[i][0].Text, true
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPhraseSides(t *testing.T) {
+	front, back, ok := splitPhraseSides("der Hund = the dog #german")
+	assert.True(t, ok)
+	assert.Equal(t, "der Hund", front)
+	assert.Equal(t, "the dog", back)
+
+	front, back, ok = splitPhraseSides("die Katze\nthe cat #german")
+	assert.True(t, ok)
+	assert.Equal(t, "die Katze", front)
+	assert.Equal(t, "the cat", back)
+
+	_, _, ok = splitPhraseSides("Guten Morgen #german")
+	assert.False(t, ok)
+}
+
+func TestNewQuiz(t *testing.T) {
+	quiz := newQuiz("der Hund", "the dog", []string{"the cat", "the dog", "the cat", "the bird", "the fish"})
+	assert.NotNil(t, quiz)
+	assert.Len(t, quiz.Options, quizOptionsCount)
+	assert.Equal(t, "the dog", quiz.Options[quiz.CorrectOption])
+	assert.ElementsMatch(t, []string{"the dog", "the cat", "the bird", "the fish"}, quiz.Options)
+
+	// Not enough distinct distractors
+	assert.Nil(t, newQuiz("der Hund", "the dog", []string{"the cat", "the cat", "the dog"}))
+}
+
+func TestBuildQuiz(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	assert.Equal(t, models.ReviewModeRate, setup.user.ReviewMode)
+
+	german, err := db.CreateTag(&models.Tag{Name: "#german"})
+	assert.NoError(t, err)
+
+	var phrases []*models.Phrase
+	for i, text := range []string{"der Hund = the dog", "die Katze = the cat", "der Vogel = the bird", "der Fisch = the fish", "Guten Morgen"} {
+		phrase, err := db.CreatePhrase(&models.Phrase{
+			UserID:            setup.user.ID,
+			TelegramMessageID: 1000 + i,
+			Text:              text + " #german",
+			Tags:              []models.Tag{*german},
+		})
+		assert.NoError(t, err)
+		phrases = append(phrases, phrase)
+	}
+
+	quiz, err := setup.app.buildQuiz(setup.user, phrases[0])
+	assert.NoError(t, err)
+	assert.NotNil(t, quiz)
+	assert.Equal(t, "der Hund", quiz.Question)
+	assert.ElementsMatch(t, []string{"the dog", "the cat", "the bird", "the fish"}, quiz.Options)
+
+	// One-sided phrases are reviewed by rating
+	quiz, err = setup.app.buildQuiz(setup.user, phrases[4])
+	assert.NoError(t, err)
+	assert.Nil(t, quiz)
+}
+
+func TestQuizCallbackIsGradedAgainstThePhrase(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+	ctx := context.Background()
+
+	phrase, err := db.CreatePhrase(&models.Phrase{UserID: setup.user.ID, TelegramMessageID: 1000, Text: "der Hund = the dog"})
+	assert.NoError(t, err)
+	other, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+	secret, err := db.CreatePhrase(&models.Phrase{UserID: other.ID, TelegramMessageID: 1001, Text: "das Geheimnis = the secret"})
+	assert.NoError(t, err)
+	session, err := db.CreateSession(&models.Session{UserID: setup.user.ID})
+	assert.NoError(t, err)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("the cat", "quiz:x:x:0")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("the dog", "quiz:x:x:1")),
+	)
+	answer := func(phraseID uint, option string) {
+		setup.app.handleQuizCallback(ctx, &tgbotapi.CallbackQuery{
+			ID:   "callback",
+			From: &tgbotapi.User{ID: setup.user.TelegramChatID},
+			Message: &tgbotapi.Message{
+				MessageID:   1,
+				Chat:        &tgbotapi.Chat{ID: setup.user.TelegramChatID},
+				ReplyMarkup: &keyboard,
+			},
+		}, []string{"quiz", strconv.Itoa(int(session.ID)), strconv.Itoa(int(phraseID)), option})
+	}
+
+	// Forged callbacks neither reveal nor grade anything
+	answer(secret.ID, "1")
+	answer(phrase.ID, "7")
+	assert.Empty(t, telegram.sent("editMessageText"))
+
+	// Only the option's index is sent back, the grade comes from the phrase
+	answer(phrase.ID, "1")
+	review, err := db.FindReview(setup.user.ID, phrase.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, quizCorrectQuality, review.RecallQuality)
+	assert.Contains(t, telegram.sent("editMessageText")[0].Params.Get("text"), "the dog")
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+// replyKeywords maps the words users can reply to a saved phrase with to the
+// phrase actions they trigger. The reply has to be the keyword alone, so
+// replies that merely mention one are left alone.
+var replyKeywords = map[string]string{
+	"delete":    phraseActionDelete,
+	"suspend":   phraseActionSuspend,
+	"unsuspend": phraseActionUnsuspend,
+	"unmaster":  phraseActionUnmaster,
+	"reset":     phraseActionReset,
+}
+
+// handlePhraseReply manages the phrase saved from the message being replied
+// to. It reports whether the reply was handled, so other replies can still be
+// saved as new phrases.
+func (app *App) handlePhraseReply(ctx context.Context, message *tgbotapi.Message) bool {
+	phrase := app.DB.FindPhraseByMessageId(message.Chat.ID, message.ReplyToMessage.MessageID)
+	if phrase == nil {
+		return false
+	}
+
+	user, err := app.DB.FindUserByTelegramID(message.From.ID)
+	if err != nil || user == nil || phrase.UserID != user.ID {
+		return false
+	}
+
+	action, isAction := replyKeywords[strings.ToLower(strings.TrimSpace(message.Text))]
+	hashtags := extractHashtags(message.Text)
+
+	switch {
+	case action == phraseActionDelete:
+		app.confirmPhraseDeletion(ctx, user, phrase, message)
+		return true
+	case isAction:
+		if err := app.applyPhraseAction(user, phrase, action); err != nil {
+			slog.ErrorContext(ctx, "Failed to apply the phrase action", "action", action, "error", err)
+			return true
+		}
+	case len(hashtags) > 0 && removeHashtags(message.Text) == "":
+		// Only hashtags tag the phrase, replies with more text are new phrases
+		if err := app.addPhraseTags(phrase, hashtags); err != nil {
+			slog.ErrorContext(ctx, "Error adding tags to phrase", "error", err)
+			return true
+		}
+	default:
+		return false
+	}
+
+	reply := tgbotapi.NewMessage(message.Chat.ID, printer(user).Sprintf("Phrase updated!"))
+	reply.ReplyToMessageID = message.MessageID
+	if _, err := app.TelegramBot.Send(reply); err != nil {
+		slog.ErrorContext(ctx, "Sending the reply confirmation failed", "error", err)
+	}
+
+	return true
+}
+
+// confirmPhraseDeletion asks before deleting the phrase, with the same Delete
+// button the /phrase keyboard has.
+func (app *App) confirmPhraseDeletion(ctx context.Context, user *models.User, phrase *models.Phrase, message *tgbotapi.Message) {
+	p := printer(user)
+	callbackData := "phrase:" + strconv.Itoa(int(phrase.ID)) + ":" + phraseActionDelete
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, p.Sprintf("Delete phrase %s?", formatID(phrase.ID))+"\n\n"+formatPhraseWithTags(phrase))
+	msg.ReplyToMessageID = message.MessageID
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Delete"), callbackData),
+		),
+	)
+	if _, err := app.TelegramBot.Send(msg); err != nil {
+		slog.ErrorContext(ctx, "Sending the delete confirmation failed", "error", err)
+	}
+}
+
+func (app *App) addPhraseTags(phrase *models.Phrase, hashtags []string) error {
+	newTags, err := app.findOrCreateTags(hashtags)
+	if err != nil {
+		return err
+	}
+
+	tags := phrase.Tags
+	for _, newTag := range newTags {
+		if !containsTag(tags, newTag) {
+			tags = append(tags, newTag)
+		}
+	}
+
+	return app.DB.UpdatePhraseTags(phrase, &tags)
+}
+
+func containsTag(tags []models.Tag, tag models.Tag) bool {
+	for _, existing := range tags {
+		if existing.ID == tag.ID {
+			return true
+		}
+	}
+	return false
+}
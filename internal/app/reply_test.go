@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPhraseReply(setup *testSetup, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID:      2000,
+		From:           &tgbotapi.User{ID: setup.user.TelegramChatID},
+		Chat:           &tgbotapi.Chat{ID: setup.user.TelegramChatID, Type: "private"},
+		Text:           text,
+		ReplyToMessage: &tgbotapi.Message{MessageID: setup.phrase.TelegramMessageID},
+	}
+}
+
+func TestPhraseReplyOnlyActsOnAKeywordAlone(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	ctx := context.Background()
+
+	// Replies mentioning keywords are saved as new phrases instead
+	assert.False(t, setup.app.handlePhraseReply(ctx, newPhraseReply(setup, "don't delete this")))
+	assert.False(t, setup.app.handlePhraseReply(ctx, newPhraseReply(setup, "reset delete")))
+	assert.Empty(t, telegram.sent("sendMessage"))
+
+	assert.True(t, setup.app.handlePhraseReply(ctx, newPhraseReply(setup, " Suspend ")))
+	phrase, err := setup.app.DB.FindPhrase(setup.phrase.ID)
+	assert.NoError(t, err)
+	assert.True(t, phrase.IsSuspended)
+}
+
+func TestPhraseReplyAsksBeforeDeleting(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+
+	assert.True(t, setup.app.handlePhraseReply(context.Background(), newPhraseReply(setup, "delete")))
+
+	_, err := setup.app.findUserPhrase(setup.user, setup.phrase.ID)
+	assert.NoError(t, err)
+
+	sent := telegram.sent("sendMessage")
+	assert.Len(t, sent, 1)
+	assert.Contains(t, sent[0].Params.Get("text"), "Delete phrase "+strconv.Itoa(int(setup.phrase.ID))+"?")
+	assert.Contains(t, sent[0].Params.Get("reply_markup"), "phrase:"+strconv.Itoa(int(setup.phrase.ID))+":delete")
+}
+
+func TestPhraseReplyWithTextIsSavedAsNewPhrase(t *testing.T) {
+	setup := setupTestReview(t)
+	setupTestTelegram(t, setup.app)
+	ctx := context.Background()
+
+	message := newPhraseReply(setup, "new phrase #tag")
+	assert.False(t, setup.app.handlePhraseReply(ctx, message))
+
+	setup.app.handleUpdate(tgbotapi.Update{Message: message})
+	phrase := setup.app.DB.FindPhraseByMessageId(setup.user.TelegramChatID, message.MessageID)
+	assert.NotNil(t, phrase)
+	assert.Equal(t, "new phrase #tag", phrase.Text)
+
+	// The phrase replied to keeps its tags
+	phrase = setup.app.DB.FindPhraseByMessageId(setup.user.TelegramChatID, setup.phrase.TelegramMessageID)
+	assert.Empty(t, phrase.Tags)
+
+	assert.True(t, setup.app.handlePhraseReply(ctx, newPhraseReply(setup, "#german #food")))
+	phrase = setup.app.DB.FindPhraseByMessageId(setup.user.TelegramChatID, setup.phrase.TelegramMessageID)
+	assert.Len(t, phrase.Tags, 2)
+}
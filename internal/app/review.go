@@ -1,24 +1,38 @@
 package app
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"math"
 	"time"
 
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/internal/logging"
 	"github.com/kiasaty/phrase-mate/models"
 )
 
 func (app *App) ReviewPhrase(
 	phraseID, userID, sessionID uint,
 	recallQuality models.RecallQuality,
+) (*models.Review, error) {
+	return app.reviewPhrase(app.DB, phraseID, userID, sessionID, recallQuality)
+}
+
+// reviewPhrase schedules the phrase by the recall quality in db. It returns a
+// nil review when the phrase isn't due yet.
+func (app *App) reviewPhrase(
+	db database.DatabaseClient,
+	phraseID, userID, sessionID uint,
+	recallQuality models.RecallQuality,
 ) (*models.Review, error) {
 	if !recallQuality.IsValid() {
 		return nil, errors.New("invalid recall quality")
 	}
 
 	// Fetch the last review for the given PhraseID and UserID
-	lastReview, err := app.DB.FindReview(userID, phraseID)
+	lastReview, err := db.FindReview(userID, phraseID)
 	if err != nil {
 		return nil, err
 	}
@@ -75,13 +89,13 @@ func (app *App) ReviewPhrase(
 		NextReviewAt:  &nextReviewAt,
 	}
 
-	if err := app.storeReview(review); err != nil {
+	if err := app.storeReview(db, review); err != nil {
 		return nil, err
 	}
 
 	// Check if the phrase should be retired
 	if newInterval >= uint16(app.Config.MaxIntervalDays) {
-		if err := app.markPhraseAsMastered(phraseID); err != nil {
+		if err := app.markPhraseAsMastered(db, phraseID); err != nil {
 			return nil, err
 		}
 	}
@@ -89,103 +103,167 @@ func (app *App) ReviewPhrase(
 	return review, nil
 }
 
-func (app *App) SendNextPhraseToReviewForAllUsers() {
+// UserSessionPlan is what sending the due phrases does for one user. Plan is
+// nil when the user was skipped.
+type UserSessionPlan struct {
+	User *models.User
+	Plan *SessionPlan
+}
+
+// SendNextPhraseToReviewForAllUsers sends every user their next phrase and
+// returns the plans it followed, after closing the sessions left idle. A dry
+// run only works the plans out. Users whose phrase couldn't be queued don't
+// hold up the others, their errors are returned together.
+func (app *App) SendNextPhraseToReviewForAllUsers(dryRun bool) ([]*UserSessionPlan, error) {
+	ctx := context.Background()
+
 	users, err := app.DB.GetAllUsers()
 	if err != nil {
-		log.Printf("Error retrieving users: %v", err)
-		return
+		return nil, fmt.Errorf("retrieving the users: %w", err)
+	}
+
+	var errs []error
+	if !dryRun {
+		if err := app.CloseIdleSessions(); err != nil {
+			errs = append(errs, fmt.Errorf("closing the idle sessions: %w", err))
+		}
 	}
 
+	plans := make([]*UserSessionPlan, 0, len(users))
 	for _, user := range users {
-		app.SendNextPhraseToReviewForUser(user)
+		plan, err := app.queueNextPhraseToReview(logging.With(ctx, "user_id", user.ID), user, dryRun)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", user.ID, err))
+		}
+		plans = append(plans, &UserSessionPlan{User: user, Plan: plan})
+	}
+
+	if !dryRun {
+		if err := app.DispatchOutbox(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("dispatching the outbox: %w", err))
+		}
 	}
+
+	return plans, errors.Join(errs...)
 }
 
-func (app *App) SendNextPhraseToReviewForUser(user *models.User) {
-	session, err := app.GetOrStartSession(user.ID)
-	if err != nil {
-		log.Printf("Fetching the active session failed: %v", err)
-		return
+// runScheduler sends the due phrases to all users every SchedulerInterval
+// until the process exits.
+func (app *App) runScheduler() {
+	ticker := time.NewTicker(app.Config.SchedulerInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := app.SendNextPhraseToReviewForAllUsers(false); err != nil {
+			slog.Error("Sending the due phrases failed", "error", err)
+		}
 	}
-	if session == nil {
-		log.Printf("No active session was found for user: %d", user.ID)
-		return
+}
+
+func (app *App) SendNextPhraseToReviewForUser(ctx context.Context, user *models.User, dryRun bool) (*SessionPlan, error) {
+	plan, err := app.queueNextPhraseToReview(ctx, user, dryRun)
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	if err := app.DispatchOutbox(ctx); err != nil {
+		return plan, fmt.Errorf("dispatching the outbox: %w", err)
+	}
+
+	return plan, nil
+}
+
+// queueNextPhraseToReview puts the next phrase of the user in the outbox,
+// along with the session changes it comes with, and returns the plan it
+// followed, nil when the user was skipped. A dry run leaves it at the plan.
+func (app *App) queueNextPhraseToReview(ctx context.Context, user *models.User, dryRun bool) (*SessionPlan, error) {
+	if !user.CanReview() {
+		slog.DebugContext(ctx, "Skipping user who can't review", "status", user.Status, "blocked", user.IsBlocked)
+		return nil, nil
 	}
 
-	phrase, err := app.getNextPhraseToReview(session)
+	plan, err := app.PlanSession(user.ID)
 	if err != nil {
-		log.Printf("Finding the next phrase to review failed: %v", err)
-		return
+		return nil, fmt.Errorf("planning the session: %w", err)
 	}
-	if phrase == nil {
-		log.Printf("No phrase was found to review for session: %d", session.ID)
-		return
+	if dryRun {
+		return plan, nil
 	}
 
-	err = app.SendPhrase(
-		user.TelegramChatID,
-		session.ID,
-		phrase.ID,
-		removeHashtags(phrase.Text),
-	)
+	var session *models.Session
+	err = app.DB.Transaction(func(tx database.DatabaseClient) error {
+		session, err = app.applySessionPlan(tx, user.ID, plan)
+		if err != nil || session == nil {
+			return err
+		}
+
+		if err := tx.TouchSession(session.ID); err != nil {
+			return err
+		}
+
+		return enqueuePhrase(tx, user, session.ID, plan.Phrase)
+	})
 	if err != nil {
-		log.Printf("Sending the phrase failed: %v", err)
-		return
+		return nil, fmt.Errorf("queueing the next phrase: %w", err)
+	}
+	if session == nil {
+		slog.InfoContext(ctx, "No phrase was found to review")
+		return plan, nil
 	}
+
+	slog.DebugContext(ctx, "Queued the next phrase", "session_id", session.ID, "phrase_id", plan.Phrase.ID)
+	return plan, nil
 }
 
 func (app *App) getNextPhraseToReview(session *models.Session) (*models.Phrase, error) {
+	phrase, _, err := app.findNextPhrase(app.DB, session.UserID)
+	return phrase, err
+}
+
+// findNextPhrase returns the phrase the user reviews next along with the
+// review it's due by, or a nil review when it's a new phrase.
+func (app *App) findNextPhrase(db database.DatabaseClient, userID uint) (*models.Phrase, *models.Review, error) {
 	sessionSize := app.Config.SessionSize
 	now := time.Now()
 
-	dueReview, err := app.DB.GetDueReview(session.UserID, now, sessionSize)
+	dueReview, err := db.GetDueReview(userID, now, sessionSize)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if dueReview != nil {
-		phrase, err := app.DB.FindPhrase(dueReview.PhraseID)
+		phrase, err := db.FindPhrase(dueReview.PhraseID)
 		if err != nil {
-			return nil, err
-		}
-
-		// Skip mastered phrases
-		if phrase.IsMastered {
-			return app.getNextPhraseToReview(session)
+			return nil, nil, err
 		}
 
-		return phrase, nil
+		return phrase, dueReview, nil
 	}
 
-	newPhraseIDs, err := app.DB.FindNewPhrasesToReview(session.UserID, 1)
+	newPhraseIDs, err := db.FindNewPhrasesToReview(userID, 1)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if len(newPhraseIDs) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	phrase, err := app.DB.FindPhrase(newPhraseIDs[0])
+	phrase, err := db.FindPhrase(newPhraseIDs[0])
 	if err != nil {
-		return nil, err
-	}
-
-	// Skip mastered phrases
-	if phrase.IsMastered {
-		return app.getNextPhraseToReview(session)
+		return nil, nil, err
 	}
 
-	return phrase, nil
+	return phrase, nil, nil
 }
 
-func (app *App) storeReview(review *models.Review) error {
-	return app.DB.CreateReview(review)
+func (app *App) storeReview(db database.DatabaseClient, review *models.Review) error {
+	return db.CreateReview(review)
 }
 
 func (app *App) GetReviewHistory(userID uint, phraseID uint) ([]*models.ReviewHistory, error) {
 	return app.DB.FindReviewHistory(userID, phraseID)
 }
 
-func (app *App) markPhraseAsMastered(phraseID uint) error {
-	return app.DB.MarkPhraseAsMastered(phraseID)
+func (app *App) markPhraseAsMastered(db database.DatabaseClient, phraseID uint) error {
+	return db.MarkPhraseAsMastered(phraseID)
 }
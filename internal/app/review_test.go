@@ -1,9 +1,13 @@
 package app
 
 import (
+	"context"
+	"strconv"
 	"testing"
 	"time"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/config"
 	"github.com/kiasaty/phrase-mate/internal/database"
 	"github.com/kiasaty/phrase-mate/models"
 	"github.com/stretchr/testify/assert"
@@ -18,12 +22,12 @@ func setupTestDB(t *testing.T) *database.Client {
 	}
 
 	// Migrate the schema
-	err = db.AutoMigrate(&models.User{}, &models.Phrase{}, &models.Review{}, &models.ReviewHistory{}, &models.Session{})
-	if err != nil {
-		t.Fatalf("Failed to migrate database: %v", err)
+	client := &database.Client{DB: db}
+	if err := client.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate the database: %v", err)
 	}
 
-	return &database.Client{DB: db}
+	return client
 }
 
 type testSetup struct {
@@ -32,9 +36,15 @@ type testSetup struct {
 	phrase *models.Phrase
 }
 
+// testConfig returns the app settings the bot runs with by default.
+func testConfig() Config {
+	defaults := config.Default()
+	return NewConfig(&defaults)
+}
+
 func setupTestReview(t *testing.T) *testSetup {
 	db := setupTestDB(t)
-	app := NewApp(db, nil, GetDefaultConfig())
+	app := NewApp(db, nil, testConfig())
 
 	// Create test user
 	user := &models.User{
@@ -49,6 +59,7 @@ func setupTestReview(t *testing.T) *testSetup {
 	// Create test phrase
 	phrase := &models.Phrase{
 		UserID:            user.ID,
+		TelegramChatID:    user.TelegramChatID,
 		TelegramMessageID: 456,
 		Text:              "Test phrase",
 	}
@@ -159,7 +170,7 @@ func TestPhraseMastery(t *testing.T) {
 		db := setupTestDB(t)
 		defer cleanupTestDB(db)
 
-		config := GetDefaultConfig()
+		config := testConfig()
 		config.MaxIntervalDays = 180 // 6 months
 		app := NewApp(db, nil, config)
 
@@ -229,7 +240,7 @@ func TestPhraseMastery(t *testing.T) {
 		db := setupTestDB(t)
 		defer cleanupTestDB(db)
 
-		config := GetDefaultConfig()
+		config := testConfig()
 		config.MaxIntervalDays = 180 // 6 months
 		app := NewApp(db, nil, config)
 
@@ -297,7 +308,7 @@ func TestPhraseMastery(t *testing.T) {
 		db := setupTestDB(t)
 		defer cleanupTestDB(db)
 
-		config := GetDefaultConfig()
+		config := testConfig()
 		config.MaxIntervalDays = 180 // 6 months
 		app := NewApp(db, nil, config)
 
@@ -385,3 +396,47 @@ func cleanupTestDB(db *database.Client) {
 		&models.Session{},
 	)
 }
+
+func TestReviewCallbackOnlyRatesOwnSessions(t *testing.T) {
+	setup := setupTestReview(t)
+	setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+	ctx := context.Background()
+
+	other, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+	otherPhrase, err := db.CreatePhrase(&models.Phrase{UserID: other.ID, TelegramChatID: 321, TelegramMessageID: 789, Text: "Geheimnis"})
+	assert.NoError(t, err)
+
+	session, err := db.CreateSession(&models.Session{UserID: setup.user.ID})
+	assert.NoError(t, err)
+	otherSession, err := db.CreateSession(&models.Session{UserID: other.ID})
+	assert.NoError(t, err)
+
+	review := func(fromID int64, sessionID uint, phraseID uint) {
+		setup.app.handleReviewCallback(ctx, &tgbotapi.CallbackQuery{
+			ID:      "callback",
+			From:    &tgbotapi.User{ID: fromID},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: fromID}},
+		}, []string{"review", strconv.Itoa(int(sessionID)), strconv.Itoa(int(phraseID)), "5"})
+	}
+
+	// Forged callbacks neither rate nor move another user's session on
+	review(setup.user.TelegramChatID, otherSession.ID, otherPhrase.ID)
+	review(setup.user.TelegramChatID, session.ID, otherPhrase.ID)
+	review(999, session.ID, setup.phrase.ID)
+	assert.Empty(t, findOutboxMessages(t, setup.app))
+	for _, userID := range []uint{setup.user.ID, other.ID} {
+		history, err := setup.app.GetReviewHistory(userID, otherPhrase.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, history)
+	}
+	history, err := setup.app.GetReviewHistory(setup.user.ID, setup.phrase.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	review(setup.user.TelegramChatID, session.ID, setup.phrase.ID)
+	history, err = setup.app.GetReviewHistory(setup.user.ID, setup.phrase.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+const phrasesPageSize = 10
+
+type searchQuery struct {
+	Text    string
+	TagName string
+}
+
+// parseSearchQuery splits the command arguments into the text to look for
+// and an optional #tag to filter the results by.
+func parseSearchQuery(arguments string) searchQuery {
+	query := searchQuery{Text: removeHashtags(arguments)}
+
+	hashtags := extractHashtags(arguments)
+	if len(hashtags) > 0 {
+		query.TagName = strings.ToLower(hashtags[0])
+	}
+
+	return query
+}
+
+func (app *App) handleSearchCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	query := parseSearchQuery(message.CommandArguments())
+	if query.Text == "" && query.TagName == "" {
+		if err := app.SendText(message.Chat.ID, printer(user).Sprintf("Usage: /search <query> [#tag]")); err != nil {
+			slog.ErrorContext(ctx, "Sending the search usage failed", "error", err)
+		}
+		return
+	}
+
+	app.sendPhrasesPage(ctx, user, message, "search", query)
+}
+
+func (app *App) handleListCommand(ctx context.Context, user *models.User, message *tgbotapi.Message) {
+	app.sendPhrasesPage(ctx, user, message, "list", parseListQuery(message.CommandArguments()))
+}
+
+// parseListQuery only keeps the #tag filter, /list doesn't search by text.
+func parseListQuery(arguments string) searchQuery {
+	query := parseSearchQuery(arguments)
+	query.Text = ""
+	return query
+}
+
+func (app *App) sendPhrasesPage(ctx context.Context, user *models.User, message *tgbotapi.Message, callbackPrefix string, query searchQuery) {
+	text, markup, err := app.renderPhrasesPage(user, query, callbackPrefix, 0)
+	if err != nil {
+		slog.ErrorContext(ctx, "Searching phrases failed", "error", err)
+		return
+	}
+
+	// Reply to the command so the query can be recovered when paginating
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	msg.ReplyToMessageID = message.MessageID
+	if markup != nil {
+		msg.ReplyMarkup = *markup
+	}
+
+	if _, err := app.TelegramBot.Send(msg); err != nil {
+		slog.ErrorContext(ctx, "Sending the search results failed", "error", err)
+	}
+}
+
+// handlePhrasesPageCallback moves a /search or /list result message to another page.
+func (app *App) handlePhrasesPageCallback(ctx context.Context, callbackQuery *tgbotapi.CallbackQuery, data []string) {
+	if len(data) != 2 {
+		slog.WarnContext(ctx, "Invalid callback data", "data", callbackQuery.Data)
+		return
+	}
+
+	page, err := strconv.Atoi(data[1])
+	if err != nil || page < 0 {
+		slog.WarnContext(ctx, "Invalid search page", "page", data[1])
+		return
+	}
+
+	message := callbackQuery.Message
+	if message == nil || message.ReplyToMessage == nil {
+		slog.WarnContext(ctx, "Search results message without the original query")
+		return
+	}
+
+	user, err := app.DB.FindUserByTelegramID(callbackQuery.From.ID)
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+
+	arguments := message.ReplyToMessage.CommandArguments()
+	query := parseSearchQuery(arguments)
+	if data[0] == "list" {
+		query = parseListQuery(arguments)
+	}
+
+	text, markup, err := app.renderPhrasesPage(user, query, data[0], page)
+	if err != nil {
+		slog.ErrorContext(ctx, "Searching phrases failed", "error", err)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, text)
+	edit.ReplyMarkup = markup
+	if _, err := app.TelegramBot.Send(edit); err != nil {
+		slog.ErrorContext(ctx, "Failed to update the search results", "error", err)
+	}
+
+	if _, err := app.TelegramBot.Request(tgbotapi.NewCallback(callbackQuery.ID, "")); err != nil {
+		slog.ErrorContext(ctx, "Failed to send callback response", "error", err)
+	}
+}
+
+func (app *App) renderPhrasesPage(user *models.User, query searchQuery, callbackPrefix string, page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	phrases, total, err := app.DB.SearchPhrases(
+		user.ID,
+		query.Text,
+		query.TagName,
+		page*phrasesPageSize,
+		phrasesPageSize,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := printer(user)
+	if total == 0 {
+		return p.Sprintf("No phrases found."), nil, nil
+	}
+
+	pageCount := (int(total) + phrasesPageSize - 1) / phrasesPageSize
+
+	var builder strings.Builder
+	builder.WriteString(p.Sprintf("Found %d phrases (page %d/%d):", total, page+1, pageCount) + "\n")
+	for _, phrase := range phrases {
+		fmt.Fprintf(&builder, "\n%d) %s", phrase.ID, formatPhraseWithTags(phrase))
+	}
+	builder.WriteString("\n\n" + p.Sprintf("Use /phrase <id> to manage a phrase."))
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("« Prev"), callbackPrefix+":"+strconv.Itoa(page-1)))
+	}
+	if page+1 < pageCount {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(p.Sprintf("Next »"), callbackPrefix+":"+strconv.Itoa(page+1)))
+	}
+
+	if len(buttons) == 0 {
+		return builder.String(), nil, nil
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(buttons)
+	return builder.String(), &markup, nil
+}
+
+func formatPhraseWithTags(phrase *models.Phrase) string {
+	text := removeHashtags(phrase.Text)
+	if phrase.MediaType != models.MediaTypeNone {
+		text = "[" + string(phrase.MediaType) + "] " + text
+	}
+
+	var tagNames []string
+	for _, tag := range phrase.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	if len(tagNames) == 0 {
+		return text
+	}
+
+	return text + " (" + strings.Join(tagNames, " ") + ")"
+}
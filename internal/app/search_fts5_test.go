@@ -0,0 +1,40 @@
+//go:build sqlite_fts5 || fts5
+
+package app
+
+import (
+	"testing"
+
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchIndexFollowsPhraseEdits(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	assert.Equal(t, "fts5", database.SearchBackend)
+	assert.True(t, db.(*database.Client).DB.Migrator().HasTable("phrases_fts"))
+
+	// Terms match as prefixes
+	phrases, _, err := db.SearchPhrases(setup.user.ID, "Test phr", "", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, phrases, 1)
+
+	setup.phrase.Text = "Guten Abend"
+	assert.NoError(t, db.UpdatePhrase(setup.phrase))
+
+	_, total, err := db.SearchPhrases(setup.user.ID, "test", "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), total)
+
+	_, total, err = db.SearchPhrases(setup.user.ID, "abend", "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), total)
+
+	assert.NoError(t, db.(*database.Client).DB.Unscoped().Delete(&models.Phrase{}, setup.phrase.ID).Error)
+	_, total, err = db.SearchPhrases(setup.user.ID, "abend", "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), total)
+}
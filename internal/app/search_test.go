@@ -0,0 +1,55 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	query := parseSearchQuery("guten #German morgen")
+	assert.Equal(t, "guten morgen", query.Text)
+	assert.Equal(t, "#german", query.TagName)
+
+	query = parseSearchQuery("hola")
+	assert.Equal(t, "hola", query.Text)
+	assert.Equal(t, "", query.TagName)
+}
+
+func TestSearchPhrases(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	german := &models.Tag{Name: "#german"}
+	german, err := db.CreateTag(german)
+	assert.NoError(t, err)
+
+	_, err = db.CreatePhrase(&models.Phrase{
+		UserID:            setup.user.ID,
+		TelegramMessageID: 789,
+		Text:              "Guten Morgen #german",
+		Tags:              []models.Tag{*german},
+	})
+	assert.NoError(t, err)
+	_, err = db.CreatePhrase(&models.Phrase{
+		UserID:            setup.user.ID,
+		TelegramMessageID: 790,
+		Text:              "Good morning #english",
+	})
+	assert.NoError(t, err)
+
+	phrases, total, err := db.SearchPhrases(setup.user.ID, "morgen", "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), total)
+	assert.Len(t, phrases, 1)
+
+	phrases, total, err = db.SearchPhrases(setup.user.ID, "", "#german", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), total)
+	assert.Equal(t, "Guten Morgen #german", phrases[0].Text)
+
+	_, total, err = db.SearchPhrases(setup.user.ID+1, "morgen", "", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), total)
+}
@@ -1,12 +1,118 @@
 package app
 
 import (
+	"context"
+	"log/slog"
+	"slices"
 	"strconv"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/models"
+	"golang.org/x/text/message"
 )
 
-func (app *App) SendPhrase(chatID int64, sessionID uint, phraseID uint, phraseText string) error {
+// SendPhrase sends the phrase for review in the session and returns the ID of
+// the message it went out as.
+func (app *App) SendPhrase(ctx context.Context, user *models.User, sessionID uint, phrase *models.Phrase) (int, error) {
+	chatID := user.TelegramChatID
+
+	if user.ReviewMode == models.ReviewModeQuiz && phrase.MediaType == models.MediaTypeNone {
+		quiz, err := app.buildQuiz(user, phrase)
+		if err != nil {
+			slog.ErrorContext(ctx, "Building a quiz failed", "phrase_id", phrase.ID, "error", err)
+		}
+		if quiz != nil {
+			return app.sendQuiz(user, sessionID, phrase, quiz)
+		}
+	}
+
+	if asksTypedAnswer(user, phrase) {
+		return app.sendTypedPrompt(user, sessionID, phrase)
+	}
+
+	markup := reviewKeyboard(sessionID, phrase.ID)
+	if phrase.MediaType.HidesAnswer() {
+		markup = showAnswerKeyboard(printer(user), sessionID, phrase.ID)
+	}
+	phraseText := removeHashtags(phrase.Text)
+
+	var msg tgbotapi.Chattable
+	switch phrase.MediaType {
+	case models.MediaTypeVoice:
+		voice := tgbotapi.NewVoice(chatID, tgbotapi.FileID(phrase.FileID))
+		voice.Caption = phraseText
+		voice.ReplyMarkup = markup
+		msg = voice
+	case models.MediaTypeAudio:
+		audio := tgbotapi.NewAudio(chatID, tgbotapi.FileID(phrase.FileID))
+		audio.Caption = phraseText
+		audio.ReplyMarkup = markup
+		msg = audio
+	case models.MediaTypePhoto:
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(phrase.FileID))
+		photo.ReplyMarkup = markup
+		msg = photo
+	case models.MediaTypeSticker:
+		sticker := tgbotapi.NewSticker(chatID, tgbotapi.FileID(phrase.FileID))
+		sticker.ReplyMarkup = markup
+		msg = sticker
+	default:
+		message := tgbotapi.NewMessage(chatID, phraseText)
+		message.ReplyMarkup = markup
+		msg = message
+
+		if voicePath := app.synthesizePhrase(ctx, user, phrase); voicePath != "" {
+			voice := tgbotapi.NewVoice(chatID, tgbotapi.FilePath(voicePath))
+			voice.Caption = phraseText
+			voice.ReplyMarkup = markup
+			msg = voice
+		}
+	}
+
+	sentMessage, err := app.TelegramBot.Send(msg)
+	return sentMessage.MessageID, err
+}
+
+// synthesizePhrase returns the path of a text-to-speech voice note for the
+// phrase, or an empty string when TTS is off for the user and the phrase tags.
+func (app *App) synthesizePhrase(ctx context.Context, user *models.User, phrase *models.Phrase) string {
+	if app.TTS == nil {
+		return ""
+	}
+
+	ttsTagIDs, err := app.DB.FindTTSEnabledTagIDs(user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Finding the voice note tags failed", "error", err)
+		return ""
+	}
+
+	enabled := user.TTSEnabled
+	tagName := ""
+	for _, tag := range phrase.Tags {
+		if tagName == "" {
+			tagName = strings.TrimPrefix(tag.Name, "#")
+		}
+		if slices.Contains(ttsTagIDs, tag.ID) {
+			enabled = true
+			tagName = strings.TrimPrefix(tag.Name, "#")
+			break
+		}
+	}
+	if !enabled {
+		return ""
+	}
+
+	path, err := app.TTS.Synthesize(removeHashtags(phrase.Text), tagName)
+	if err != nil {
+		slog.ErrorContext(ctx, "Synthesizing phrase failed", "phrase_id", phrase.ID, "error", err)
+		return ""
+	}
+
+	return path
+}
+
+func reviewKeyboard(sessionID uint, phraseID uint) tgbotapi.InlineKeyboardMarkup {
 	buttonKeyPrefix := "review:" + strconv.Itoa(int(sessionID)) + ":" + strconv.Itoa(int(phraseID))
 	buttons := []tgbotapi.InlineKeyboardButton{
 		tgbotapi.NewInlineKeyboardButtonData("1", buttonKeyPrefix+":1"),
@@ -16,9 +122,34 @@ func (app *App) SendPhrase(chatID int64, sessionID uint, phraseID uint, phraseTe
 		tgbotapi.NewInlineKeyboardButtonData("5", buttonKeyPrefix+":5"),
 	}
 
-	msg := tgbotapi.NewMessage(chatID, phraseText)
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons)
+	return tgbotapi.NewInlineKeyboardMarkup(buttons)
+}
+
+func showAnswerKeyboard(p *message.Printer, sessionID uint, phraseID uint) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				p.Sprintf("Show answer"),
+				"answer:"+strconv.Itoa(int(sessionID))+":"+strconv.Itoa(int(phraseID)),
+			),
+		),
+	)
+}
 
-	_, err := app.TelegramBot.Send(msg)
+func (app *App) SendText(chatID int64, text string) error {
+	_, err := app.sendText(chatID, text)
 	return err
 }
+
+// sendText sends the text and returns the ID of the message it went out as.
+func (app *App) sendText(chatID int64, text string) (int, error) {
+	sentMessage, err := app.TelegramBot.Send(tgbotapi.NewMessage(chatID, text))
+	return sentMessage.MessageID, err
+}
+
+func formatSessionSummary(p *message.Printer, summary *sessionSummary) string {
+	return p.Sprintf("Session finished!") + "\n\n" +
+		p.Sprintf("You reviewed %d phrases.", summary.ReviewedCount) + "\n" +
+		p.Sprintf("Average recall quality: %.1f", summary.AverageQuality) + "\n" +
+		p.Sprintf("%d phrases are due tomorrow.", summary.DueTomorrow)
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/tts"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSynthesizePhrase(t *testing.T) {
+	setup := setupTestReview(t)
+	setup.app.TTS = tts.NewEngine("tee {output}", t.TempDir())
+
+	db := setup.app.DB
+	tag, err := db.CreateTag(&models.Tag{Name: "#german"})
+	assert.NoError(t, err)
+	other, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+
+	phrase := &models.Phrase{ID: 1, Text: "Guten Morgen #german", Tags: []models.Tag{*tag}}
+
+	// Off for the user and the tag
+	assert.Equal(t, "", setup.app.synthesizePhrase(context.Background(), setup.user, phrase))
+
+	// Enabled for the tag, only for the user who turned it on
+	assert.NoError(t, db.SetUserTagTTSEnabled(setup.user.ID, tag.ID, true))
+	assert.NotEqual(t, "", setup.app.synthesizePhrase(context.Background(), setup.user, phrase))
+	assert.Equal(t, "", setup.app.synthesizePhrase(context.Background(), other, phrase))
+
+	// Turned off again
+	assert.NoError(t, db.SetUserTagTTSEnabled(setup.user.ID, tag.ID, false))
+	assert.Equal(t, "", setup.app.synthesizePhrase(context.Background(), setup.user, phrase))
+
+	// Enabled for the user
+	setup.user.TTSEnabled = true
+	assert.NotEqual(t, "", setup.app.synthesizePhrase(context.Background(), setup.user, phrase))
+}
+
+func TestShowAnswerOnlyRevealsOwnPhrases(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+	ctx := context.Background()
+
+	other, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+	secret, err := db.CreatePhrase(&models.Phrase{UserID: other.ID, TelegramMessageID: 789, Text: "Geheimnis", MediaType: models.MediaTypeSticker})
+	assert.NoError(t, err)
+
+	session, err := db.CreateSession(&models.Session{UserID: setup.user.ID})
+	assert.NoError(t, err)
+	otherSession, err := db.CreateSession(&models.Session{UserID: other.ID})
+	assert.NoError(t, err)
+
+	showAnswer := func(sessionID uint, phraseID uint) {
+		setup.app.handleShowAnswerCallback(ctx, &tgbotapi.CallbackQuery{
+			ID:      "callback",
+			From:    &tgbotapi.User{ID: setup.user.TelegramChatID},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: setup.user.TelegramChatID}},
+		}, []string{"answer", strconv.Itoa(int(sessionID)), strconv.Itoa(int(phraseID))})
+	}
+
+	// Forged callbacks reveal nothing
+	showAnswer(session.ID, secret.ID)
+	showAnswer(otherSession.ID, setup.phrase.ID)
+	assert.Empty(t, telegram.sent("answerCallbackQuery"))
+
+	showAnswer(session.ID, setup.phrase.ID)
+	callbacks := telegram.sent("answerCallbackQuery")
+	assert.Len(t, callbacks, 1)
+	assert.Equal(t, "Test phrase", callbacks[0].Params.Get("text"))
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/kiasaty/phrase-mate/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// serveHTTP runs the admin HTTP server with the metrics, health checks and
+// profiling endpoints until the process exits.
+func (app *App) serveHTTP() {
+	prometheus.MustRegister(&dueReviewsCollector{app: app})
+
+	ctx := context.Background()
+	slog.InfoContext(ctx, "Serving HTTP", "addr", app.Config.HTTPAddr)
+	if err := http.ListenAndServe(app.Config.HTTPAddr, app.httpHandler()); err != nil {
+		slog.ErrorContext(ctx, "HTTP server stopped", "error", err)
+	}
+}
+
+func (app *App) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", app.handleHealthz)
+	mux.HandleFunc("/readyz", app.handleReadyz)
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
+
+// handleHealthz reports the process as alive as long as the long-poll loop
+// keeps coming back from Telegram.
+func (app *App) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if app.isPollingStuck() {
+		http.Error(w, "polling for updates is stuck", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Write([]byte("ok\n"))
+}
+
+// handleReadyz reports whether the database and the Telegram Bot API can be
+// reached.
+func (app *App) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if err := app.DB.Ping(); err != nil {
+		slog.ErrorContext(r.Context(), "Database is not reachable", "error", err)
+		http.Error(w, "database is not reachable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if _, err := app.TelegramBot.GetMe(); err != nil {
+		slog.ErrorContext(r.Context(), "Telegram is not reachable", "error", err)
+		http.Error(w, "telegram is not reachable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Write([]byte("ok\n"))
+}
+
+// minPollingStuckAfter keeps a short poll timeout from getting a slow handler
+// taken for stuck polling.
+const minPollingStuckAfter = time.Minute
+
+// isPollingStuck tells whether the long-poll loop hasn't heard back from
+// Telegram for twice the poll timeout, or a minute at least. A loop that
+// hasn't started yet isn't stuck.
+func (app *App) isPollingStuck() bool {
+	lastPollAt := app.lastPollAt.Load()
+	if lastPollAt == 0 {
+		return false
+	}
+
+	return time.Since(time.Unix(lastPollAt, 0)) > max(2*app.Config.PollTimeout, minPollingStuckAfter)
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleHealthz(t *testing.T) {
+	setup := setupTestReview(t)
+	handler := setup.app.httpHandler()
+
+	healthz := func() int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return recorder.Code
+	}
+
+	// Polling hasn't started yet
+	assert.Equal(t, http.StatusOK, healthz())
+
+	setup.app.lastPollAt.Store(time.Now().Unix())
+	assert.Equal(t, http.StatusOK, healthz())
+
+	stalledAt := time.Now().Add(-3 * setup.app.Config.PollTimeout)
+	setup.app.lastPollAt.Store(stalledAt.Unix())
+	assert.Equal(t, http.StatusServiceUnavailable, healthz())
+
+	// Short poll timeouts leave slow handlers some slack
+	setup.app.Config.PollTimeout = time.Second
+	setup.app.lastPollAt.Store(time.Now().Add(-10 * time.Second).Unix())
+	assert.Equal(t, http.StatusOK, healthz())
+
+	setup.app.lastPollAt.Store(time.Now().Add(-2 * time.Minute).Unix())
+	assert.Equal(t, http.StatusServiceUnavailable, healthz())
+}
@@ -1,53 +1,276 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/internal/metrics"
 	"github.com/kiasaty/phrase-mate/models"
 )
 
 func (app *App) GetOrStartSession(userID uint) (*models.Session, error) {
+	plan, err := app.PlanSession(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.applySessionPlan(app.DB, userID, plan)
+}
+
+// applySessionPlan carries out the plan in db and returns the session that
+// goes on, or nil if there is none.
+func (app *App) applySessionPlan(db database.DatabaseClient, userID uint, plan *SessionPlan) (*models.Session, error) {
+	if plan.EndedSession != nil {
+		if err := app.endSession(db, plan.EndedSession, plan.EndReason); err != nil {
+			return nil, err
+		}
+	}
+
+	if plan.Session != nil {
+		return plan.Session, nil
+	}
+	if !plan.StartsSession {
+		return nil, nil
+	}
+
+	return app.startSession(db, userID)
+}
+
+// SessionPlan is what GetOrStartSession is going to do for a user and the
+// phrase the session continues with.
+type SessionPlan struct {
+	// Session is the active session that goes on, nil when there is none
+	Session *models.Session
+	// EndedSession is the active session that gets closed first
+	EndedSession *models.Session
+	EndReason    models.SessionEndReason
+	// StartsSession is set when a new session gets opened
+	StartsSession bool
+
+	Phrase *models.Phrase
+	// DueReview is the review the phrase is due by, nil for a new phrase
+	DueReview *models.Review
+}
+
+// PlanSession works out the session of the user without changing anything,
+// so it's also what dry runs report.
+func (app *App) PlanSession(userID uint) (*SessionPlan, error) {
+	plan := &SessionPlan{}
+
 	activeSession, err := app.findActiveSession(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	if activeSession == nil {
-		return app.startSession(userID)
+	// Close sessions the user walked away from
+	if activeSession != nil && app.isSessionIdle(activeSession) {
+		plan.EndedSession, plan.EndReason = activeSession, models.SessionEndTimeout
+		activeSession = nil
 	}
 
-	phrase, err := app.getNextPhraseToReview(activeSession)
+	// Carries on with the active session, or starts a new one as long as
+	// there is something to review
+	plan.Phrase, plan.DueReview, err = app.findNextPhrase(app.DB, userID)
 	if err != nil {
 		return nil, err
 	}
-	if phrase != nil {
-		return activeSession, err
+
+	switch {
+	case activeSession != nil && plan.Phrase != nil:
+		plan.Session = activeSession
+	case activeSession != nil:
+		plan.EndedSession, plan.EndReason = activeSession, models.SessionEndExhausted
+	case plan.Phrase != nil:
+		plan.StartsSession = true
 	}
 
-	activeSession, err = app.startSession(userID)
+	return plan, nil
+}
+
+// findUserSession finds a session and makes sure it belongs to the given user.
+func (app *App) findUserSession(user *models.User, sessionID uint) (*models.Session, error) {
+	session, err := app.DB.FindSession(sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	return activeSession, nil
+	if session.UserID != user.ID {
+		return nil, errors.New("session does not belong to the user")
+	}
+
+	return session, nil
 }
 
 func (app *App) findActiveSession(userID uint) (*models.Session, error) {
 	return app.DB.FindActiveSession(userID)
 }
 
-func (app *App) startSession(userID uint) (*models.Session, error) {
-	session, err := app.DB.CreateSession(&models.Session{
-		UserID:    userID,
-		StartedAt: time.Now(),
+func (app *App) startSession(db database.DatabaseClient, userID uint) (*models.Session, error) {
+	now := time.Now()
+	session, err := db.CreateSession(&models.Session{
+		UserID:         userID,
+		StartedAt:      now,
+		LastActivityAt: &now,
 	})
 	if err != nil {
 		return nil, err
 	}
+	metrics.SessionsStarted.Inc()
 
 	return session, nil
 }
 
-func (app *App) endSession(sessionID uint) error {
-	return app.DB.EndSession(sessionID)
+// endSession closes the session and queues the removal of the keyboards that
+// were left unanswered in it.
+func (app *App) endSession(db database.DatabaseClient, session *models.Session, reason models.SessionEndReason) error {
+	stats, err := db.GetSessionStats(session.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := db.EndSession(session.ID, reason, stats); err != nil {
+		return err
+	}
+	metrics.SessionsEnded.WithLabelValues(string(reason)).Inc()
+
+	return enqueueKeyboardExpiry(db, session)
+}
+
+func (app *App) isSessionIdle(session *models.Session) bool {
+	return time.Since(session.LastActiveAt()) > app.Config.SessionIdleTimeout
+}
+
+// CloseIdleSessions closes the sessions of all users who walked away from
+// them and queues their summaries, so they don't stay open until the user
+// gets their next phrase. The caller dispatches the outbox.
+func (app *App) CloseIdleSessions() error {
+	sessions, err := app.DB.FindIdleSessions(time.Now().Add(-app.Config.SessionIdleTimeout))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, idleSession := range sessions {
+		err := app.DB.Transaction(func(tx database.DatabaseClient) error {
+			// A rating may have come in or closed the session meanwhile
+			session, err := tx.FindSession(idleSession.ID)
+			if err != nil || session.EndedAt != nil || !app.isSessionIdle(session) {
+				return err
+			}
+
+			user, err := tx.FindUser(session.UserID)
+			if err != nil {
+				return err
+			}
+			if user == nil {
+				return app.endSession(tx, session, models.SessionEndTimeout)
+			}
+
+			return app.finishSession(tx, user, session, models.SessionEndTimeout)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("closing session %d: %w", idleSession.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// advanceSession queues the next phrase of the given session in db, or ends
+// the session and queues its summary once there is nothing left to review.
+// The caller dispatches the outbox once db is committed.
+func (app *App) advanceSession(db database.DatabaseClient, user *models.User, sessionID uint) error {
+	session, err := db.FindSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	// The session was already closed, e.g. it timed out before the rating came in
+	if session.EndedAt != nil {
+		return nil
+	}
+
+	reviewedPhrasesCount, err := db.CountReviewedPhrasesInSession(session.ID)
+	if err != nil {
+		return err
+	}
+	if reviewedPhrasesCount >= app.Config.SessionSize {
+		return app.finishSession(db, user, session, models.SessionEndCompleted)
+	}
+
+	phrase, _, err := app.findNextPhrase(db, session.UserID)
+	if err != nil {
+		return err
+	}
+	if phrase == nil {
+		return app.finishSession(db, user, session, models.SessionEndExhausted)
+	}
+
+	if err := db.TouchSession(session.ID); err != nil {
+		return err
+	}
+
+	return enqueuePhrase(db, user, session.ID, phrase)
+}
+
+func (app *App) finishSession(db database.DatabaseClient, user *models.User, session *models.Session, reason models.SessionEndReason) error {
+	summary, err := app.getSessionSummary(db, session)
+	if err != nil {
+		return err
+	}
+
+	if err := app.endSession(db, session, reason); err != nil {
+		return err
+	}
+
+	return enqueueText(db, user, formatSessionSummary(printer(user), summary))
+}
+
+type sessionSummary struct {
+	ReviewedCount  uint
+	AverageQuality float64
+	DueTomorrow    uint
+}
+
+func (app *App) getSessionSummary(db database.DatabaseClient, session *models.Session) (*sessionSummary, error) {
+	stats, err := db.GetSessionStats(session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Phrases overdue already aren't due tomorrow
+	now := time.Now()
+	startOfTomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	startOfDayAfter := startOfTomorrow.AddDate(0, 0, 1)
+
+	dueTomorrow, err := db.CountReviewsDueBetween(session.UserID, startOfTomorrow, startOfDayAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionSummary{
+		ReviewedCount:  stats.ReviewedCount,
+		AverageQuality: stats.AverageQuality,
+		DueTomorrow:    dueTomorrow,
+	}, nil
+}
+
+// EndActiveSession closes the active session of the user on an operator's
+// request and returns it, or nil if the user had none.
+func (app *App) EndActiveSession(userID uint) (*models.Session, error) {
+	session, err := app.findActiveSession(userID)
+	if err != nil || session == nil {
+		return nil, err
+	}
+
+	err = app.DB.Transaction(func(tx database.DatabaseClient) error {
+		return app.endSession(tx, session, models.SessionEndForced)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return app.DB.FindSession(session.ID)
 }
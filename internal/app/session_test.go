@@ -0,0 +1,183 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSessionSummary(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	session, err := db.CreateSession(&models.Session{UserID: setup.user.ID})
+	assert.NoError(t, err)
+
+	otherPhrase, err := db.CreatePhrase(&models.Phrase{
+		UserID:            setup.user.ID,
+		TelegramMessageID: 789,
+		Text:              "Another phrase",
+	})
+	assert.NoError(t, err)
+
+	_, err = setup.app.ReviewPhrase(setup.phrase.ID, setup.user.ID, session.ID, models.QualityPerfect)
+	assert.NoError(t, err)
+	_, err = setup.app.ReviewPhrase(otherPhrase.ID, setup.user.ID, session.ID, models.QualityRemembered)
+	assert.NoError(t, err)
+
+	// Overdue phrases aren't due tomorrow
+	overduePhrase, err := db.CreatePhrase(&models.Phrase{
+		UserID:            setup.user.ID,
+		TelegramMessageID: 790,
+		Text:              "Overdue phrase",
+	})
+	assert.NoError(t, err)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	assert.NoError(t, db.CreateReview(&models.Review{
+		UserID:       setup.user.ID,
+		PhraseID:     overduePhrase.ID,
+		EaseFactor:   2.5,
+		NextReviewAt: &yesterday,
+	}))
+
+	summary, err := setup.app.getSessionSummary(setup.app.DB, session)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), summary.ReviewedCount)
+	assert.Equal(t, 4.0, summary.AverageQuality)
+	assert.Equal(t, uint(2), summary.DueTomorrow)
+}
+
+func TestGetOrStartSessionClosesIdleSession(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	lastActivity := time.Now().Add(-setup.app.Config.SessionIdleTimeout - time.Minute)
+	idleSession, err := db.CreateSession(&models.Session{
+		UserID:         setup.user.ID,
+		LastActivityAt: &lastActivity,
+	})
+	assert.NoError(t, err)
+
+	session, err := setup.app.GetOrStartSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+	assert.NotEqual(t, idleSession.ID, session.ID)
+
+	closedSession, err := db.FindSession(idleSession.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, closedSession.EndedAt)
+	assert.Equal(t, models.SessionEndTimeout, closedSession.EndReason)
+}
+
+func TestCloseIdleSessions(t *testing.T) {
+	setup := setupTestReview(t)
+	telegram := setupTestTelegram(t, setup.app)
+	db := setup.app.DB
+
+	other, err := db.CreateUser(&models.User{TelegramChatID: 321})
+	assert.NoError(t, err)
+
+	lastActivity := time.Now().Add(-setup.app.Config.SessionIdleTimeout - time.Minute)
+	idleSession, err := db.CreateSession(&models.Session{UserID: setup.user.ID, LastActivityAt: &lastActivity})
+	assert.NoError(t, err)
+	activeSession, err := db.CreateSession(&models.Session{UserID: other.ID})
+	assert.NoError(t, err)
+
+	// Idle sessions are closed without waiting for the user's next phrase
+	assert.NoError(t, setup.app.CloseIdleSessions())
+	assert.NoError(t, setup.app.DispatchOutbox(context.Background()))
+
+	closedSession, err := db.FindSession(idleSession.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, closedSession.EndedAt)
+	assert.Equal(t, models.SessionEndTimeout, closedSession.EndReason)
+
+	sent := telegram.sent("sendMessage")
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "123", sent[0].Params.Get("chat_id"))
+	assert.Contains(t, sent[0].Params.Get("text"), "Session finished!")
+
+	session, err := db.FindSession(activeSession.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, session.EndedAt)
+}
+
+func TestGetOrStartSessionWithoutPhrasesToReview(t *testing.T) {
+	setup := setupTestReview(t)
+
+	_, err := setup.app.ReviewPhrase(setup.phrase.ID, setup.user.ID, 1, models.QualityPerfect)
+	assert.NoError(t, err)
+
+	session, err := setup.app.GetOrStartSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+}
+
+func TestEndActiveSession(t *testing.T) {
+	setup := setupTestReview(t)
+
+	session, err := setup.app.EndActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+
+	activeSession, err := setup.app.GetOrStartSession(setup.user.ID)
+	assert.NoError(t, err)
+
+	session, err = setup.app.EndActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, activeSession.ID, session.ID)
+	assert.NotNil(t, session.EndedAt)
+	assert.Equal(t, models.SessionEndForced, session.EndReason)
+}
+
+func TestPlanSessionChangesNothing(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	lastActivity := time.Now().Add(-setup.app.Config.SessionIdleTimeout - time.Minute)
+	idleSession, err := db.CreateSession(&models.Session{
+		UserID:         setup.user.ID,
+		LastActivityAt: &lastActivity,
+	})
+	assert.NoError(t, err)
+
+	plan, err := setup.app.PlanSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, idleSession.ID, plan.EndedSession.ID)
+	assert.Equal(t, models.SessionEndTimeout, plan.EndReason)
+	assert.True(t, plan.StartsSession)
+	assert.Equal(t, setup.phrase.ID, plan.Phrase.ID)
+	assert.Nil(t, plan.DueReview)
+
+	activeSession, err := db.FindActiveSession(setup.user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, idleSession.ID, activeSession.ID)
+	assert.Nil(t, activeSession.EndedAt)
+}
+
+func TestReviewIsRolledBackWhenTheSessionCantGoOn(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	session, err := db.CreateSession(&models.Session{UserID: setup.user.ID})
+	assert.NoError(t, err)
+
+	// Nothing can be queued, so neither ending the session nor its summary is
+	assert.NoError(t, db.(*database.Client).DB.Migrator().DropTable(&models.OutboxMessage{}))
+
+	reviewed, err := setup.app.handleReview(setup.user, session.ID, setup.phrase.ID, models.QualityPerfect)
+	assert.Error(t, err)
+	assert.False(t, reviewed)
+
+	review, err := db.FindReview(setup.user.ID, setup.phrase.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, review)
+
+	session, err = db.FindSession(session.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, session.EndedAt)
+}
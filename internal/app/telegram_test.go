@@ -0,0 +1,97 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/telegram"
+)
+
+// fakeTelegramResult answers every Bot API method, it reads as the bot user
+// for getMe and as a sent message for the rest.
+const fakeTelegramResult = `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Bot","username":"bot","message_id":%d,"date":0,"chat":{"id":1,"type":"private"}}}`
+
+type fakeTelegramRequest struct {
+	Method string
+	Params url.Values
+}
+
+// fakeTelegram records the Bot API requests of the bot instead of sending
+// them.
+type fakeTelegram struct {
+	mu       sync.Mutex
+	requests []fakeTelegramRequest
+	// down makes the requests fail like Telegram can't be reached
+	down bool
+	// forbidden makes the requests fail like the user blocked the bot
+	forbidden bool
+	// messageID is the ID of the last message the bot sent
+	messageID int
+}
+
+func (f *fakeTelegram) Do(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		req.ParseMultipartForm(1 << 20)
+	} else {
+		req.ParseForm()
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, fakeTelegramRequest{
+		Method: path.Base(req.URL.Path),
+		Params: req.Form,
+	})
+	down, forbidden := f.down, f.forbidden
+	if !down && !forbidden && path.Base(req.URL.Path) != "getMe" {
+		f.messageID++
+	}
+	messageID := f.messageID
+	f.mu.Unlock()
+
+	if down {
+		return nil, errors.New("telegram is down")
+	}
+	if forbidden {
+		return nil, &telegram.ForbiddenError{Description: "Forbidden: bot was blocked by the user"}
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(fakeTelegramResult, messageID))),
+	}, nil
+}
+
+// sent returns the requests of the given method, after getMe.
+func (f *fakeTelegram) sent(method string) []fakeTelegramRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var requests []fakeTelegramRequest
+	for _, request := range f.requests {
+		if request.Method == method {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// setupTestTelegram gives the app a bot that talks to a fake Bot API.
+func setupTestTelegram(t *testing.T, app *App) *fakeTelegram {
+	fake := &fakeTelegram{}
+
+	bot, err := tgbotapi.NewBotAPIWithClient("token", tgbotapi.APIEndpoint, fake)
+	if err != nil {
+		t.Fatalf("Failed to create the bot: %v", err)
+	}
+	app.TelegramBot = bot
+
+	return fake
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+// similarityToQuality maps how close a typed answer is to the expected one
+// to a recall quality.
+func similarityToQuality(similarity float64) models.RecallQuality {
+	switch {
+	case similarity >= 1:
+		return models.QualityPerfect
+	case similarity >= 0.9:
+		return models.QualityFluent
+	case similarity >= 0.75:
+		return models.QualityRemembered
+	case similarity >= 0.5:
+		return models.QualityHesitant
+	default:
+		return models.QualityForgot
+	}
+}
+
+// asksTypedAnswer tells whether the phrase is put to the user as a typed
+// prompt, which comes without a keyboard.
+func asksTypedAnswer(user *models.User, phrase *models.Phrase) bool {
+	_, _, twoSided := splitPhraseSides(phrase.Text)
+
+	return user.ReviewMode == models.ReviewModeTyped && phrase.MediaType == models.MediaTypeNone && twoSided
+}
+
+// sendTypedPrompt sends the front of a two-sided phrase and waits for the
+// user to type its back.
+func (app *App) sendTypedPrompt(user *models.User, sessionID uint, phrase *models.Phrase) (int, error) {
+	front, _, _ := splitPhraseSides(phrase.Text)
+
+	err := app.startConversation(
+		user.TelegramChatID,
+		user,
+		models.ConversationAwaitingAnswer,
+		sessionID,
+		phrase.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return app.sendText(user.TelegramChatID, front+"\n\n"+printer(user).Sprintf("Type your answer:"))
+}
+
+// handleTypedAnswer grades the answer typed for the phrase the conversation
+// is waiting on and moves the session on.
+func (app *App) handleTypedAnswer(ctx context.Context, message *tgbotapi.Message, conversation *models.Conversation) {
+	ctx = logging.With(ctx, "session_id", conversation.SessionID, "phrase_id", conversation.PhraseID)
+
+	user, err := app.DB.FindUserByTelegramID(message.From.ID)
+	if err != nil || user == nil {
+		slog.ErrorContext(ctx, "User not found", "error", err)
+		return
+	}
+
+	phrase, err := app.DB.FindPhrase(conversation.PhraseID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Phrase not found", "error", err)
+		return
+	}
+
+	_, back, _ := splitPhraseSides(phrase.Text)
+	similarity := answerSimilarity(message.Text, back)
+	recallQuality := similarityToQuality(similarity)
+
+	reviewed, err := app.handleReview(user, conversation.SessionID, phrase.ID, recallQuality)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to handle review", "error", err)
+		return
+	}
+
+	p := printer(user)
+	result := p.Sprintf("✅ Correct!")
+	if recallQuality != models.QualityPerfect {
+		result = p.Sprintf("%.0f%% match, the answer is: %s", similarity*100, back)
+	}
+
+	reply := tgbotapi.NewMessage(message.Chat.ID, result)
+	reply.ReplyToMessageID = message.MessageID
+	if _, err := app.TelegramBot.Send(reply); err != nil {
+		slog.ErrorContext(ctx, "Sending the answer result failed", "error", err)
+	}
+
+	if !reviewed {
+		return
+	}
+
+	// Send the next phrase or the summary queued with the review
+	if err := app.DispatchOutbox(ctx); err != nil {
+		slog.ErrorContext(ctx, "Dispatching the outbox failed", "error", err)
+	}
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarityToQuality(t *testing.T) {
+	assert.Equal(t, models.QualityPerfect, similarityToQuality(answerSimilarity("the dog", "The dog")))
+	assert.Equal(t, models.QualityRemembered, similarityToQuality(answerSimilarity("the dag", "the dog")))
+	assert.Equal(t, models.QualityForgot, similarityToQuality(answerSimilarity("a cat", "the dog")))
+}
+
+func TestSaveConversationReplacesTheChatState(t *testing.T) {
+	setup := setupTestReview(t)
+	db := setup.app.DB
+
+	for _, phraseID := range []uint{1, 2} {
+		err := db.SaveConversation(&models.Conversation{
+			TelegramChatID: setup.user.TelegramChatID,
+			UserID:         setup.user.ID,
+			State:          models.ConversationAwaitingAnswer,
+			SessionID:      1,
+			PhraseID:       phraseID,
+		})
+		assert.NoError(t, err)
+	}
+
+	conversation, err := db.FindConversation(setup.user.TelegramChatID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), conversation.PhraseID)
+
+	assert.NoError(t, db.DeleteConversation(setup.user.TelegramChatID))
+
+	conversation, err = db.FindConversation(setup.user.TelegramChatID)
+	assert.NoError(t, err)
+	assert.Nil(t, conversation)
+}
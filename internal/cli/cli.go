@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/kiasaty/phrase-mate/internal/app"
+	"github.com/kiasaty/phrase-mate/internal/config"
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/internal/metrics"
+	"github.com/kiasaty/phrase-mate/internal/telegram"
+)
+
+// Exit codes of Run.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// usageError marks errors caused by invalid arguments rather than a failure.
+type usageError struct {
+	error
+}
+
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// configError marks errors caused by settings the command can't run with.
+type configError struct {
+	error
+}
+
+type CLI struct {
+	Stdout io.Writer
+	Stderr io.Writer
+
+	config *config.Config
+}
+
+// Run runs the command given in args, which don't include the program name,
+// and returns the exit code.
+func (c *CLI) Run(args []string) int {
+	if len(args) == 0 {
+		c.printUsage(c.Stderr)
+		return ExitUsage
+	}
+	if args[0] == "help" {
+		return c.runHelp(args[1:])
+	}
+
+	cfg, args, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		c.printUsage(c.Stdout)
+		return ExitOK
+	}
+	if err != nil {
+		fmt.Fprintf(c.Stderr, "Invalid configuration:\n%v\n", err)
+		return ExitUsage
+	}
+	c.config = cfg
+	slog.SetDefault(logging.New(c.Stderr, cfg.LogLevel))
+
+	if len(args) == 0 {
+		c.printUsage(c.Stderr)
+		return ExitUsage
+	}
+
+	cmd, args := findCommand(args)
+	if cmd == nil {
+		fmt.Fprintf(c.Stderr, "Unknown command %q, run 'phrase-mate help' for the list of commands.\n", args[0])
+		return ExitUsage
+	}
+
+	flags := cmd.flagSet()
+	run := cmd.define(flags)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			c.printCommandUsage(c.Stdout, cmd)
+			return ExitOK
+		}
+		fmt.Fprintf(c.Stderr, "%v\n\n", err)
+		c.printCommandUsage(c.Stderr, cmd)
+		return ExitUsage
+	}
+
+	if err := run(c, flags.Args()); err != nil {
+		if errors.As(err, &configError{}) {
+			fmt.Fprintf(c.Stderr, "Invalid configuration:\n%v\n", err)
+			return ExitUsage
+		}
+		if errors.As(err, &usageError{}) {
+			fmt.Fprintf(c.Stderr, "%v\n\n", err)
+			c.printCommandUsage(c.Stderr, cmd)
+			return ExitUsage
+		}
+		fmt.Fprintf(c.Stderr, "%v\n", err)
+		return ExitError
+	}
+
+	return ExitOK
+}
+
+func (c *CLI) runHelp(args []string) int {
+	if len(args) == 0 {
+		c.printUsage(c.Stdout)
+		return ExitOK
+	}
+
+	cmd, _ := findCommand(args)
+	if cmd == nil {
+		fmt.Fprintf(c.Stderr, "Unknown command %q.\n", strings.Join(args, " "))
+		return ExitUsage
+	}
+
+	c.printCommandUsage(c.Stdout, cmd)
+	return ExitOK
+}
+
+func (c *CLI) printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: phrase-mate [global flags] <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-28s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags, also read from the environment in upper snake case:")
+	config.PrintFlags(w)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'phrase-mate help <command>' for the flags of a command.")
+}
+
+func (c *CLI) printCommandUsage(w io.Writer, cmd *command) {
+	fmt.Fprintf(w, "Usage: phrase-mate [global flags] %s [flags]", cmd.name)
+	if cmd.args != "" {
+		fmt.Fprintf(w, " %s", cmd.args)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, cmd.summary)
+
+	flags := cmd.flagSet()
+	cmd.define(flags)
+
+	hasFlags := false
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Flags:")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+}
+
+// newApp connects to the database, and to Telegram when the command talks to
+// users, and returns the app for the command to work with.
+func (c *CLI) newApp(withTelegram bool) (*app.App, error) {
+	if err := c.config.Validate(withTelegram); err != nil {
+		return nil, configError{err}
+	}
+
+	databaseClient, err := database.NewDatabaseClient(c.config.DatabaseDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the database client: %w", err)
+	}
+
+	if !withTelegram {
+		return app.NewApp(databaseClient, nil, app.NewConfig(c.config)), nil
+	}
+
+	bot, err := tgbotapi.NewBotAPI(c.config.TelegramBotToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Telegram bot: %w", err)
+	}
+
+	app := app.NewApp(databaseClient, bot, app.NewConfig(c.config))
+	bot.Client = metrics.InstrumentTelegramClient(telegram.NewClient(bot.Client))
+
+	return app, nil
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kiasaty/phrase-mate/internal/database"
+	"github.com/kiasaty/phrase-mate/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestCLI(t *testing.T) {
+	t.Setenv("TELEGRAM_BOT_TOKEN", "secret-token")
+	t.Setenv("DATABASE_DSN", filepath.Join(t.TempDir(), "database.sqlite"))
+}
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	c := &CLI{Stdout: &stdout, Stderr: &stderr}
+	code := c.Run(args)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunHelp(t *testing.T) {
+	setupTestCLI(t)
+
+	code, _, stderr := runCLI()
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, "send-due-phrases-to-review")
+
+	code, stdout, _ := runCLI("help")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "list-users")
+	assert.Contains(t, stdout, "-session-size")
+
+	code, stdout, _ = runCLI("show-queue", "-h")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "show-queue [flags] <user-id>")
+	assert.Contains(t, stdout, "-limit")
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	setupTestCLI(t)
+
+	code, _, stderr := runCLI("unknown")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, `Unknown command "unknown"`)
+
+	code, _, stderr = runCLI("send-due-phrases-to-review", "--user", "nope")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, "-user")
+
+	code, _, stderr = runCLI("end-session")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, "expected a single user ID")
+}
+
+func TestRunAdminCommands(t *testing.T) {
+	setupTestCLI(t)
+
+	code, stdout, _ := runCLI("migrate-database")
+	assert.Equal(t, ExitOK, code)
+	assert.Empty(t, stdout)
+
+	code, stdout, _ = runCLI("config", "show")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "session_size: 20")
+	assert.NotContains(t, stdout, "secret-token")
+
+	code, stdout, _ = runCLI("list-users")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "TELEGRAM ID")
+
+	code, _, stderr := runCLI("end-session", "42")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "user 42 not found")
+}
+
+func TestRunSendDuePhrasesDryRun(t *testing.T) {
+	setupTestCLI(t)
+
+	code, _, _ := runCLI("migrate-database")
+	assert.Equal(t, ExitOK, code)
+
+	db, err := database.NewDatabaseClient(os.Getenv("DATABASE_DSN"))
+	assert.NoError(t, err)
+	user, err := db.CreateUser(&models.User{TelegramChatID: 123, Username: "learner"})
+	assert.NoError(t, err)
+	phrase, err := db.CreatePhrase(&models.Phrase{UserID: user.ID, TelegramMessageID: 456, Text: "Guten Morgen #german"})
+	assert.NoError(t, err)
+
+	code, stdout, _ := runCLI("send-due-phrases-to-review", "--dry-run")
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, fmt.Sprintf("User %d (@learner): start a new session, send phrase %d, new: Guten Morgen #german\n", user.ID, phrase.ID), stdout)
+
+	session, err := db.FindActiveSession(user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	setupTestCLI(t)
+
+	// The users can't be read before the schema is created
+	code, _, stderr := runCLI("send-due-phrases-to-review", "--dry-run")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "retrieving the users")
+
+	dsn := filepath.Join(t.TempDir(), "readonly.sqlite")
+	assert.NoError(t, os.WriteFile(dsn, nil, 0o644))
+	t.Setenv("DATABASE_DSN", "file:"+dsn+"?mode=ro")
+
+	code, _, stderr = runCLI("migrate-database")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "migrating the schema")
+}
+
+func TestRunWithoutToken(t *testing.T) {
+	setupTestCLI(t)
+	t.Setenv("TELEGRAM_BOT_TOKEN", "")
+
+	// Only the commands talking to Telegram need the token
+	code, _, _ := runCLI("migrate-database")
+	assert.Equal(t, ExitOK, code)
+
+	code, _, _ = runCLI("list-users")
+	assert.Equal(t, ExitOK, code)
+
+	code, _, stderr := runCLI("fetch-updates")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, "telegram_bot_token is required")
+
+	// The configuration is shown along with what's wrong with it
+	code, stdout, stderr := runCLI("--session-size", "0", "config", "show")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stdout, "session_size: 0")
+	assert.Contains(t, stderr, "telegram_bot_token is required")
+	assert.Contains(t, stderr, "session_size must be positive")
+}
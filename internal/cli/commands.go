@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kiasaty/phrase-mate/internal/app"
+	"github.com/kiasaty/phrase-mate/internal/logging"
+	"github.com/kiasaty/phrase-mate/models"
+)
+
+// runFunc runs a command with the arguments left after its flags.
+type runFunc func(c *CLI, args []string) error
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	// define adds the flags of the command and returns the function running
+	// it with their parsed values
+	define func(flags *flag.FlagSet) runFunc
+}
+
+func (cmd *command) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	return flags
+}
+
+var commands = []*command{
+	{
+		name:    "fetch-updates",
+		summary: "Poll Telegram for updates and handle them",
+		define: func(flags *flag.FlagSet) runFunc {
+			return func(c *CLI, args []string) error {
+				app, err := c.newApp(true)
+				if err != nil {
+					return err
+				}
+				app.Serve()
+				return nil
+			}
+		},
+	},
+	{
+		name:    "send-due-phrases-to-review",
+		summary: "Send every user, or just one, their next phrase to review",
+		define: func(flags *flag.FlagSet) runFunc {
+			userID := flags.Uint("user", 0, "only send to the user with this ID")
+			dryRun := flags.Bool("dry-run", false, "report what would be sent without sending it or changing the database")
+
+			return func(c *CLI, args []string) error {
+				// A dry run never connects to Telegram
+				app, err := c.newApp(!*dryRun)
+				if err != nil {
+					return err
+				}
+
+				if *userID == 0 {
+					plans, err := app.SendNextPhraseToReviewForAllUsers(*dryRun)
+					if *dryRun {
+						for _, plan := range plans {
+							printSessionPlan(c.Stdout, plan.User, plan.Plan)
+						}
+					}
+					return err
+				}
+
+				user, err := findUser(app, *userID)
+				if err != nil {
+					return err
+				}
+				ctx := logging.With(context.Background(), "user_id", user.ID)
+				plan, err := app.SendNextPhraseToReviewForUser(ctx, user, *dryRun)
+				if err != nil {
+					return err
+				}
+				if *dryRun {
+					printSessionPlan(c.Stdout, user, plan)
+				}
+				return nil
+			}
+		},
+	},
+	{
+		name:    "migrate-database",
+		summary: "Create or update the database schema",
+		define: func(flags *flag.FlagSet) runFunc {
+			return func(c *CLI, args []string) error {
+				app, err := c.newApp(false)
+				if err != nil {
+					return err
+				}
+				return app.DB.Migrate()
+			}
+		},
+	},
+	{
+		name:    "config show",
+		summary: "Print the effective configuration and what's wrong with it",
+		define: func(flags *flag.FlagSet) runFunc {
+			return func(c *CLI, args []string) error {
+				if err := c.config.Show(c.Stdout); err != nil {
+					return err
+				}
+				if err := c.config.Validate(true); err != nil {
+					return configError{err}
+				}
+				return nil
+			}
+		},
+	},
+	{
+		name:    "list-users",
+		summary: "List the users with their review mode and due reviews",
+		define: func(flags *flag.FlagSet) runFunc {
+			return runListUsers
+		},
+	},
+	{
+		name:    "show-queue",
+		args:    "<user-id>",
+		summary: "Show the active session and the upcoming phrases of a user",
+		define: func(flags *flag.FlagSet) runFunc {
+			limit := flags.Int("limit", 10, "number of due and new phrases to show")
+
+			return func(c *CLI, args []string) error {
+				return runShowQueue(c, args, *limit)
+			}
+		},
+	},
+	{
+		name:    "end-session",
+		args:    "<user-id>",
+		summary: "End the active session of a user",
+		define: func(flags *flag.FlagSet) runFunc {
+			return runEndSession
+		},
+	},
+}
+
+// findCommand returns the command named by the first words of args and the
+// arguments after its name.
+func findCommand(args []string) (*command, []string) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):]
+		}
+	}
+	return nil, args
+}
+
+// printSessionPlan reports what send-due-phrases-to-review does for the user,
+// the plan is nil when the user is skipped.
+func printSessionPlan(w io.Writer, user *models.User, plan *app.SessionPlan) {
+	switch {
+	case !user.CanReview():
+		reason := string(user.Status)
+		if user.IsBlocked {
+			reason = "blocked"
+		}
+		fmt.Fprintf(w, "User %d (%s): %s, skipped\n", user.ID, userLabel(user), reason)
+	case plan == nil:
+		fmt.Fprintf(w, "User %d (%s): planning failed, skipped\n", user.ID, userLabel(user))
+	default:
+		fmt.Fprintf(w, "User %d (%s): %s\n", user.ID, userLabel(user), describeSessionPlan(plan))
+	}
+}
+
+func describeSessionPlan(plan *app.SessionPlan) string {
+	var steps []string
+
+	if plan.EndedSession != nil {
+		steps = append(steps, fmt.Sprintf("end session %d (%s)", plan.EndedSession.ID, plan.EndReason))
+	}
+
+	switch {
+	case plan.Session != nil:
+		steps = append(steps, fmt.Sprintf("continue session %d", plan.Session.ID))
+	case plan.StartsSession:
+		steps = append(steps, "start a new session")
+	}
+
+	switch {
+	case plan.Phrase == nil:
+		steps = append(steps, "nothing to review")
+	case plan.DueReview != nil:
+		steps = append(steps, fmt.Sprintf("send phrase %d, due since %s: %s",
+			plan.Phrase.ID, plan.DueReview.NextReviewAt.Format(time.DateOnly), shortenText(plan.Phrase.Text)))
+	default:
+		steps = append(steps, fmt.Sprintf("send phrase %d, new: %s", plan.Phrase.ID, shortenText(plan.Phrase.Text)))
+	}
+
+	return strings.Join(steps, ", ")
+}
+
+func userLabel(user *models.User) string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+func runListUsers(c *CLI, args []string) error {
+	app, err := c.newApp(false)
+	if err != nil {
+		return err
+	}
+
+	users, err := app.DB.GetAllUsers()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(c.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTELEGRAM ID\tUSERNAME\tNAME\tMODE\tDUE")
+	for _, user := range users {
+		due, err := app.DB.CountDueReviews(user.ID, now)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%d\n", user.ID, user.TelegramChatID, user.Username, name, user.ReviewMode, due)
+	}
+
+	return w.Flush()
+}
+
+func runShowQueue(c *CLI, args []string, limit int) error {
+	userID, err := parseUserID(args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.newApp(false)
+	if err != nil {
+		return err
+	}
+	if _, err := findUser(app, userID); err != nil {
+		return err
+	}
+
+	queue, err := app.GetUserQueue(userID, limit)
+	if err != nil {
+		return err
+	}
+
+	if session := queue.ActiveSession; session != nil {
+		fmt.Fprintf(c.Stdout, "Active session %d, started %s, last active %s\n",
+			session.ID, session.StartedAt.Format(time.DateTime), session.LastActiveAt().Format(time.DateTime))
+	} else {
+		fmt.Fprintln(c.Stdout, "No active session")
+	}
+
+	fmt.Fprintf(c.Stdout, "\n%d due reviews\n", queue.DueCount)
+	for _, review := range queue.DueReviews {
+		if err := printQueuedPhrase(c, app, review.PhraseID, "due "+review.NextReviewAt.Format(time.DateOnly)); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(c.Stdout, "\nNext new phrases\n")
+	for _, phraseID := range queue.NewPhraseIDs {
+		if err := printQueuedPhrase(c, app, phraseID, "new"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printQueuedPhrase(c *CLI, app *app.App, phraseID uint, label string) error {
+	phrase, err := app.DB.FindPhrase(phraseID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stdout, "  %d\t%s\t%s\n", phrase.ID, label, shortenText(phrase.Text))
+	return nil
+}
+
+// shortenText puts the text on a single line of at most 60 characters.
+func shortenText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len([]rune(text)) > 60 {
+		text = string([]rune(text)[:60]) + "…"
+	}
+	return text
+}
+
+func runEndSession(c *CLI, args []string) error {
+	userID, err := parseUserID(args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.newApp(false)
+	if err != nil {
+		return err
+	}
+	if _, err := findUser(app, userID); err != nil {
+		return err
+	}
+
+	session, err := app.EndActiveSession(userID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		fmt.Fprintln(c.Stdout, "The user has no active session.")
+		return nil
+	}
+
+	fmt.Fprintf(c.Stdout, "Ended session %d after %d reviews.\n", session.ID, session.ReviewedCount)
+	return nil
+}
+
+func parseUserID(args []string) (uint, error) {
+	if len(args) != 1 {
+		return 0, usageErrorf("expected a single user ID")
+	}
+
+	userID, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil || userID == 0 {
+		return 0, usageErrorf("invalid user ID %q", args[0])
+	}
+
+	return uint(userID), nil
+}
+
+func findUser(app *app.App, userID uint) (*models.User, error) {
+	user, err := app.DB.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+	return user, nil
+}
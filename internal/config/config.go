@@ -0,0 +1,306 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Access modes, they name who may start using the bot.
+const (
+	AccessOpen     = "open"
+	AccessInvite   = "invite"
+	AccessApproval = "approval"
+)
+
+// Config is the effective configuration of the bot, merged from the defaults,
+// an optional config file, the environment and the command line flags, each
+// overriding the one before.
+type Config struct {
+	DatabaseDSN        string        `yaml:"database_dsn" toml:"database_dsn"`
+	TelegramBotToken   string        `yaml:"telegram_bot_token" toml:"telegram_bot_token"`
+	SessionSize        uint          `yaml:"session_size" toml:"session_size"`
+	MaxIntervalDays    int           `yaml:"max_interval_days" toml:"max_interval_days"`
+	SessionIdleTimeout time.Duration `yaml:"session_idle_timeout" toml:"session_idle_timeout"`
+	TTSCommand         string        `yaml:"tts_command" toml:"tts_command"`
+	TTSCacheDir        string        `yaml:"tts_cache_dir" toml:"tts_cache_dir"`
+	LogLevel           slog.Level    `yaml:"log_level" toml:"log_level"`
+	HTTPAddr           string        `yaml:"http_addr" toml:"http_addr"`
+	PollTimeout        time.Duration `yaml:"poll_timeout" toml:"poll_timeout"`
+	// SchedulerInterval is how often fetch-updates sends the due phrases
+	// itself, zero leaves it to an external scheduler like cron
+	SchedulerInterval time.Duration `yaml:"scheduler_interval" toml:"scheduler_interval"`
+	AdminIDs          []int64       `yaml:"admin_ids" toml:"admin_ids"`
+	AccessMode        string        `yaml:"access_mode" toml:"access_mode"`
+	InviteCodes       []string      `yaml:"invite_codes" toml:"invite_codes"`
+}
+
+// Default returns the configuration used for the settings that aren't set
+// anywhere.
+func Default() Config {
+	return Config{
+		DatabaseDSN:        "./data/database.sqlite",
+		SessionSize:        20,
+		MaxIntervalDays:    365,
+		SessionIdleTimeout: 2 * time.Hour,
+		TTSCacheDir:        "./data/tts",
+		LogLevel:           slog.LevelInfo,
+		PollTimeout:        60 * time.Second,
+		AccessMode:         AccessOpen,
+	}
+}
+
+// Load builds the configuration from the given command line arguments and
+// returns it along with the arguments left after the flags. The config file
+// is taken from the --config flag or the CONFIG_FILE environment variable and
+// variables in a .env file are loaded into the environment if it exists.
+// Validating it is left to the commands, as not all of them need every setting.
+func Load(args []string) (*Config, []string, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("loading the .env file: %w", err)
+	}
+
+	config := Default()
+	configFile := os.Getenv("CONFIG_FILE")
+
+	flags := newFlagSet(&config, &configFile)
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// Parsing wrote the flags into the config already, keep them aside to
+	// apply them again on top of the file and the environment
+	setFlags := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = f.Value.String()
+	})
+
+	config = Default()
+	if configFile != "" {
+		if err := loadFile(configFile, &config); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []error
+	flags.VisitAll(func(f *flag.Flag) {
+		// Empty variables count as unset, so a copied .env.example doesn't
+		// override the config file
+		value := os.Getenv(envName(f.Name))
+		if value == "" || f.Name == "config" {
+			return
+		}
+		if err := f.Value.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", envName(f.Name), err))
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, nil, err
+	}
+
+	for name, value := range setFlags {
+		if err := flags.Set(name, value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &config, flags.Args(), nil
+}
+
+// PrintFlags writes the flags Load accepts along with their defaults.
+func PrintFlags(w io.Writer) {
+	config := Default()
+	var configFile string
+
+	flags := newFlagSet(&config, &configFile)
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+}
+
+// newFlagSet defines a flag for every setting, the environment variable of a
+// setting is its flag name in upper snake case. Errors are left to the caller
+// to report.
+func newFlagSet(config *Config, configFile *string) *flag.FlagSet {
+	flags := flag.NewFlagSet("phrase-mate", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+
+	flags.StringVar(configFile, "config", *configFile, "path of a YAML or TOML config file")
+
+	flags.StringVar(&config.DatabaseDSN, "database-dsn", config.DatabaseDSN, "SQLite database DSN")
+	flags.StringVar(&config.TelegramBotToken, "telegram-bot-token", config.TelegramBotToken, "Telegram bot token")
+	flags.UintVar(&config.SessionSize, "session-size", config.SessionSize, "phrases reviewed per session")
+	flags.IntVar(&config.MaxIntervalDays, "max-interval-days", config.MaxIntervalDays, "longest interval between two reviews in days")
+	flags.DurationVar(&config.SessionIdleTimeout, "session-idle-timeout", config.SessionIdleTimeout, "inactivity after which a session is closed")
+	flags.StringVar(&config.TTSCommand, "tts-command", config.TTSCommand, "text-to-speech command, reads the text on stdin and writes an OGG/Opus file to {output}")
+	flags.StringVar(&config.TTSCacheDir, "tts-cache-dir", config.TTSCacheDir, "directory of the synthesized voice notes")
+	flags.TextVar(&config.LogLevel, "log-level", config.LogLevel, "one of debug, info, warn or error")
+	flags.StringVar(&config.HTTPAddr, "http-addr", config.HTTPAddr, "address of the admin HTTP server, disabled when empty")
+	flags.DurationVar(&config.PollTimeout, "poll-timeout", config.PollTimeout, "long-poll timeout for fetching updates")
+	flags.DurationVar(&config.SchedulerInterval, "scheduler-interval", config.SchedulerInterval, "how often fetch-updates sends the due phrases, disabled when zero")
+	flags.Var((*int64List)(&config.AdminIDs), "admin-ids", "comma separated Telegram IDs of the admins")
+	flags.StringVar(&config.AccessMode, "access-mode", config.AccessMode, "who may use the bot: open, invite or approval")
+	flags.Var((*stringList)(&config.InviteCodes), "invite-codes", "comma separated invite codes for the invite access mode")
+
+	return flags
+}
+
+func envName(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func loadFile(path string, config *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, config)
+	case ".toml":
+		err = toml.Unmarshal(content, config)
+	default:
+		return fmt.Errorf("unsupported config file %s, use YAML or TOML", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate reports every setting that has an invalid value. The bot token is
+// only required withTelegram, by the commands that talk to Telegram.
+func (c *Config) Validate(withTelegram bool) error {
+	var errs []error
+
+	if c.DatabaseDSN == "" {
+		errs = append(errs, errors.New("database_dsn is required"))
+	}
+	if withTelegram && c.TelegramBotToken == "" {
+		errs = append(errs, errors.New("telegram_bot_token is required"))
+	}
+	if c.SessionSize == 0 {
+		errs = append(errs, errors.New("session_size must be positive"))
+	}
+	if c.MaxIntervalDays <= 0 {
+		errs = append(errs, errors.New("max_interval_days must be positive"))
+	}
+	if c.SessionIdleTimeout <= 0 {
+		errs = append(errs, errors.New("session_idle_timeout must be positive"))
+	}
+	if c.TTSCommand != "" && c.TTSCacheDir == "" {
+		errs = append(errs, errors.New("tts_cache_dir is required with tts_command"))
+	}
+	if c.HTTPAddr != "" {
+		if _, _, err := net.SplitHostPort(c.HTTPAddr); err != nil {
+			errs = append(errs, fmt.Errorf("invalid http_addr: %w", err))
+		}
+	}
+	// Telegram takes the timeout in whole seconds
+	if c.PollTimeout < time.Second {
+		errs = append(errs, errors.New("poll_timeout must be at least 1s"))
+	}
+	if c.SchedulerInterval < 0 {
+		errs = append(errs, errors.New("scheduler_interval can't be negative"))
+	}
+	if c.AccessMode != AccessOpen && c.AccessMode != AccessInvite && c.AccessMode != AccessApproval {
+		errs = append(errs, fmt.Errorf("invalid access_mode %q, use open, invite or approval", c.AccessMode))
+	}
+	if c.AccessMode == AccessApproval && len(c.AdminIDs) == 0 {
+		errs = append(errs, errors.New("admin_ids are required with the approval access mode"))
+	}
+	for _, adminID := range c.AdminIDs {
+		if adminID <= 0 {
+			errs = append(errs, fmt.Errorf("invalid admin ID %d", adminID))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Show writes the configuration as YAML with the bot token masked.
+func (c *Config) Show(w io.Writer) error {
+	shown := *c
+	if shown.TelegramBotToken != "" {
+		shown.TelegramBotToken = "********"
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(shown); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// int64List is a flag of comma separated numbers.
+type int64List []int64
+
+func (l *int64List) String() string {
+	if l == nil {
+		return ""
+	}
+
+	values := make([]string, len(*l))
+	for i, value := range *l {
+		values[i] = strconv.FormatInt(value, 10)
+	}
+	return strings.Join(values, ",")
+}
+
+func (l *int64List) Set(value string) error {
+	var values []int64
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		number, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return err
+		}
+		values = append(values, number)
+	}
+
+	*l = values
+	return nil
+}
+
+// stringList is a flag of comma separated strings.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	var values []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			values = append(values, field)
+		}
+	}
+
+	*l = values
+	return nil
+}
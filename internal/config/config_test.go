@@ -0,0 +1,106 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write the config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+telegram_bot_token: from-file
+session_size: 10
+max_interval_days: 30
+session_idle_timeout: 30m
+log_level: warn
+admin_ids: [1, 2]
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("SESSION_SIZE", "15")
+	t.Setenv("MAX_INTERVAL_DAYS", "60")
+
+	config, args, err := Load([]string{"--session-size", "25", "send-due-phrases-to-review"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"send-due-phrases-to-review"}, args)
+
+	// Defaults
+	assert.Equal(t, "./data/database.sqlite", config.DatabaseDSN)
+	assert.Equal(t, time.Minute, config.PollTimeout)
+
+	// File
+	assert.Equal(t, "from-file", config.TelegramBotToken)
+	assert.Equal(t, 30*time.Minute, config.SessionIdleTimeout)
+	assert.Equal(t, slog.LevelWarn, config.LogLevel)
+	assert.Equal(t, []int64{1, 2}, config.AdminIDs)
+
+	// Environment over file
+	assert.Equal(t, 60, config.MaxIntervalDays)
+
+	// Flags over environment
+	assert.Equal(t, uint(25), config.SessionSize)
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", `
+telegram_bot_token = "from-file"
+scheduler_interval = "15m"
+admin_ids = [42]
+`)
+
+	config, _, err := Load([]string{"--config", path})
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, config.SchedulerInterval)
+	assert.Equal(t, []int64{42}, config.AdminIDs)
+}
+
+func TestValidate(t *testing.T) {
+	validate := func(args ...string) error {
+		config, _, err := Load(args)
+		if err != nil {
+			return err
+		}
+		return config.Validate(true)
+	}
+
+	err := validate("--session-size", "0", "--http-addr", "nope")
+	assert.ErrorContains(t, err, "telegram_bot_token is required")
+	assert.ErrorContains(t, err, "session_size must be positive")
+	assert.ErrorContains(t, err, "invalid http_addr")
+
+	err = validate("--telegram-bot-token", "x", "--access-mode", "approval")
+	assert.ErrorContains(t, err, "admin_ids are required with the approval access mode")
+
+	err = validate("--telegram-bot-token", "x", "--access-mode", "closed")
+	assert.ErrorContains(t, err, `invalid access_mode "closed"`)
+
+	err = validate("--telegram-bot-token", "x", "--poll-timeout", "0")
+	assert.ErrorContains(t, err, "poll_timeout must be at least 1s")
+
+	err = validate("--telegram-bot-token", "x", "--poll-timeout", "500ms")
+	assert.ErrorContains(t, err, "poll_timeout must be at least 1s")
+
+	err = validate("--telegram-bot-token", "x", "--poll-timeout", "1s")
+	assert.NoError(t, err)
+
+	// Commands that don't talk to Telegram do without the token
+	config, _, err := Load(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, config.Validate(false))
+	assert.ErrorContains(t, config.Validate(true), "telegram_bot_token is required")
+
+	t.Setenv("SESSION_SIZE", "many")
+	_, _, err = Load(nil)
+	assert.ErrorContains(t, err, "invalid SESSION_SIZE")
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/kiasaty/phrase-mate/models"
@@ -9,38 +10,89 @@ import (
 )
 
 type DatabaseClient interface {
-	Migrate()
+	Migrate() error
+	Ping() error
 	Transaction(fc func(tx DatabaseClient) error) error
 
 	CreateUser(user *models.User) (*models.User, error)
+	FindUser(userID uint) (*models.User, error)
 	FindUserByTelegramID(telegramID int64) (*models.User, error)
+	FindUserByUsername(username string) (*models.User, error)
 	GetAllUsers() ([]*models.User, error)
+	SetUserTTSEnabled(userID uint, enabled bool) error
+	SetUserReviewMode(userID uint, mode models.ReviewMode) error
+	SetUserLanguage(userID uint, language string) error
+	SetUserBlocked(userID uint, blocked bool) error
+	SetUserStatus(userID uint, status models.UserStatus) error
+	GetStats() (*models.Stats, error)
 
 	CreateTag(tag *models.Tag) (*models.Tag, error)
 	FindTagByName(name string) (*models.Tag, error)
+	SetUserTagTTSEnabled(userID uint, tagID uint, enabled bool) error
+	FindTTSEnabledTagIDs(userID uint) ([]uint, error)
 
 	CreatePhrase(phrase *models.Phrase) (*models.Phrase, error)
 	FindPhrase(phraseID uint) (*models.Phrase, error)
-	FindPhraseByMessageId(messageID int) (phrase *models.Phrase)
+	FindPhraseByMessageId(chatID int64, messageID int) (phrase *models.Phrase)
 	UpdatePhrase(phrase *models.Phrase) error
 	UpdatePhraseTags(phrase *models.Phrase, tags *[]models.Tag) error
 	FindNewPhrasesToReview(userID uint, limit int) ([]uint, error)
+	FindRelatedPhrases(userID uint, phraseID uint, limit int) ([]*models.Phrase, error)
+	SearchPhrases(userID uint, query string, tagName string, offset, limit int) ([]*models.Phrase, uint, error)
+
+	CreateDeck(deck *models.Deck) (*models.Deck, error)
+	FindDeck(deckID uint) (*models.Deck, error)
+	FindDeckByInviteToken(token string) (*models.Deck, error)
+	FindDeckByTelegramChatID(chatID int64) (*models.Deck, error)
+	FindOwnedDeckByName(ownerID uint, name string) (*models.Deck, error)
+	FindUserDecks(userID uint) ([]*models.Deck, error)
+	AddDeckMember(deckID uint, userID uint) error
+	RemoveDeckMember(deckID uint, userID uint) error
+	IsDeckMember(deckID uint, userID uint) (bool, error)
+	AssignTagPhrasesToDeck(deck *models.Deck) error
 
 	CreateSession(session *models.Session) (*models.Session, error)
-	EndSession(sessionID uint) error
+	EndSession(sessionID uint, reason models.SessionEndReason, stats *models.SessionStats) error
+	TouchSession(sessionID uint) error
 	FindActiveSession(userID uint) (*models.Session, error)
+	FindSession(sessionID uint) (*models.Session, error)
+	FindIdleSessions(lastActiveBefore time.Time) ([]*models.Session, error)
+	FindRecentSessions(userID uint, limit int) ([]*models.Session, error)
+	GetSessionStats(sessionID uint) (*models.SessionStats, error)
 
 	CreateReview(review *models.Review) error
 	UpdateReview(review *models.Review) error
 	FindReview(userID uint, phraseId uint) (*models.Review, error)
+	DeleteReview(userID uint, phraseID uint) error
 	CountReviewedPhrasesInSession(sessionID uint) (uint, error)
 	GetDueReview(userID uint, now time.Time, limit uint) (*models.Review, error)
+	CountDueReviews(userID uint, until time.Time) (uint, error)
+	CountReviewsDueBetween(userID uint, from time.Time, until time.Time) (uint, error)
+	FindDueReviews(userID uint, until time.Time, limit int) ([]*models.Review, error)
+
+	CreateOutboxMessage(message *models.OutboxMessage) error
+	ClaimOutboxMessages(leaseExpiredBefore time.Time, limit int) ([]*models.OutboxMessage, error)
+	MarkOutboxMessageSent(messageID uint, sentMessageID int) error
+	MarkOutboxMessageFailed(messageID uint, sendErr error, final bool, retryAt time.Time) error
+	DeferOutboxMessage(messageID uint, until time.Time) error
+
+	CreateSentMessage(message *models.SentMessage) error
+	FindSessionMessagesWithKeyboard(sessionID uint) ([]*models.SentMessage, error)
+	FindPhraseMessagesWithKeyboard(chatID int64, phraseID uint) ([]*models.SentMessage, error)
+	MarkSentMessageKeyboardRemoved(chatID int64, messageID int) error
+
+	SaveConversation(conversation *models.Conversation) error
+	FindConversation(chatID int64) (*models.Conversation, error)
+	DeleteConversation(chatID int64) error
 
 	// Review history operations
 	CreateReviewHistory(review *models.ReviewHistory) error
 	FindReviewHistory(userID uint, phraseID uint) ([]*models.ReviewHistory, error)
 
 	MarkPhraseAsMastered(phraseID uint) error
+	UnmarkPhraseAsMastered(phraseID uint) error
+	SetPhraseSuspended(phraseID uint, suspended bool) error
+	DeletePhrase(phraseID uint) error
 }
 
 type Client struct {
@@ -50,7 +102,7 @@ type Client struct {
 func NewDatabaseClient(databaseDSN string) (DatabaseClient, error) {
 	db, err := gorm.Open(
 		sqlite.Open(databaseDSN),
-		&gorm.Config{},
+		&gorm.Config{Logger: newSlogLogger()},
 	)
 
 	if err != nil {
@@ -73,13 +125,41 @@ func (c *Client) Transaction(fc func(tx DatabaseClient) error) error {
 	})
 }
 
-func (c *Client) Migrate() {
-	c.DB.AutoMigrate(
+func (c *Client) Migrate() error {
+	err := c.DB.AutoMigrate(
 		&models.User{},
 		&models.Tag{},
+		&models.UserTagSetting{},
 		&models.Phrase{},
 		&models.Review{},
 		&models.ReviewHistory{},
 		&models.Session{},
+		&models.Deck{},
+		&models.DeckMember{},
+		&models.Conversation{},
+		&models.OutboxMessage{},
+		&models.SentMessage{},
 	)
+	if err != nil {
+		return fmt.Errorf("migrating the schema: %w", err)
+	}
+
+	if err := migratePhraseChatIDs(c.DB); err != nil {
+		return fmt.Errorf("migrating the phrase chat IDs: %w", err)
+	}
+
+	if err := migrateSearchIndex(c.DB); err != nil {
+		return fmt.Errorf("migrating the search index: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) Ping() error {
+	sqlDB, err := c.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Ping()
 }
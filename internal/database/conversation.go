@@ -0,0 +1,35 @@
+package database
+
+import (
+	"github.com/kiasaty/phrase-mate/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveConversation creates or replaces the conversation of a chat.
+func (c *Client) SaveConversation(conversation *models.Conversation) error {
+	return c.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "telegram_chat_id"}},
+		UpdateAll: true,
+	}).Create(conversation).Error
+}
+
+func (c *Client) FindConversation(chatID int64) (*models.Conversation, error) {
+	var conversation models.Conversation
+
+	err := c.DB.Where("telegram_chat_id = ?", chatID).First(&conversation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &conversation, nil
+}
+
+func (c *Client) DeleteConversation(chatID int64) error {
+	return c.DB.Where("telegram_chat_id = ?", chatID).
+		Delete(&models.Conversation{}).Error
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"github.com/kiasaty/phrase-mate/models"
+	"gorm.io/gorm"
+)
+
+func (c *Client) CreateDeck(deck *models.Deck) (*models.Deck, error) {
+	if err := c.DB.Create(deck).Error; err != nil {
+		return nil, err
+	}
+	return deck, nil
+}
+
+func (c *Client) FindDeck(deckID uint) (*models.Deck, error) {
+	var deck models.Deck
+
+	if err := c.DB.Preload("Members").First(&deck, deckID).Error; err != nil {
+		return nil, err
+	}
+
+	return &deck, nil
+}
+
+func (c *Client) FindDeckByInviteToken(token string) (*models.Deck, error) {
+	var deck models.Deck
+
+	err := c.DB.Preload("Owner").Where("invite_token = ?", token).First(&deck).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &deck, nil
+}
+
+func (c *Client) FindDeckByTelegramChatID(chatID int64) (*models.Deck, error) {
+	var deck models.Deck
+
+	err := c.DB.Where("telegram_chat_id = ?", chatID).First(&deck).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &deck, nil
+}
+
+func (c *Client) FindOwnedDeckByName(ownerID uint, name string) (*models.Deck, error) {
+	var deck models.Deck
+
+	err := c.DB.Where("owner_id = ? AND name = ?", ownerID, name).First(&deck).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &deck, nil
+}
+
+func (c *Client) FindUserDecks(userID uint) ([]*models.Deck, error) {
+	var decks []*models.Deck
+
+	err := c.DB.Preload("Members").
+		Where("id IN (SELECT deck_id FROM deck_members WHERE user_id = ?)", userID).
+		Order("created_at ASC").
+		Find(&decks).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decks, nil
+}
+
+func (c *Client) AddDeckMember(deckID uint, userID uint) error {
+	return c.DB.
+		Where(models.DeckMember{DeckID: deckID, UserID: userID}).
+		FirstOrCreate(&models.DeckMember{}).Error
+}
+
+func (c *Client) RemoveDeckMember(deckID uint, userID uint) error {
+	return c.DB.Where("deck_id = ? AND user_id = ?", deckID, userID).
+		Delete(&models.DeckMember{}).Error
+}
+
+func (c *Client) IsDeckMember(deckID uint, userID uint) (bool, error) {
+	var count int64
+
+	err := c.DB.Model(&models.DeckMember{}).
+		Where("deck_id = ? AND user_id = ?", deckID, userID).
+		Count(&count).Error
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// AssignTagPhrasesToDeck moves the owner's phrases filed under the deck's
+// hashtag into the deck.
+func (c *Client) AssignTagPhrasesToDeck(deck *models.Deck) error {
+	return c.DB.Model(&models.Phrase{}).
+		Where("user_id = ?", deck.OwnerID).
+		Where(
+			"id IN (SELECT phrase_tag.phrase_id FROM phrase_tag JOIN tags ON tags.id = phrase_tag.tag_id WHERE tags.name = ?)",
+			deck.Name,
+		).
+		Update("deck_id", deck.ID).Error
+}
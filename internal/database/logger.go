@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/kiasaty/phrase-mate/internal/metrics"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// slogLogger reports failed and slow queries through slog, so they end up
+// in the same structured logs as the rest of the bot, and counts the failed
+// ones in the metrics. The client doesn't get the context of the update a
+// query runs for, so these entries carry no correlation IDs. The errors are
+// returned too, and the app logs them along with the update.
+type slogLogger struct {
+	level logger.LogLevel
+}
+
+func newSlogLogger() logger.Interface {
+	return &slogLogger{level: logger.Warn}
+}
+
+func (l *slogLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &slogLogger{level: level}
+}
+
+func (l *slogLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Info {
+		slog.Info(msg, "args", args)
+	}
+}
+
+func (l *slogLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Warn {
+		slog.Warn(msg, "args", args)
+	}
+}
+
+func (l *slogLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= logger.Error {
+		slog.Error(msg, "args", args)
+	}
+}
+
+func (l *slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		metrics.DatabaseErrors.Inc()
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		sql, rows := fc()
+		slog.Error("Database query failed", "error", err, "sql", sql, "rows", rows, "elapsed", elapsed)
+	case elapsed > slowQueryThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		slog.Warn("Slow database query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		slog.Debug("Database query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
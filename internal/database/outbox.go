@@ -0,0 +1,100 @@
+package database
+
+import (
+	"time"
+
+	"github.com/kiasaty/phrase-mate/models"
+	"gorm.io/gorm"
+)
+
+func (c *Client) CreateOutboxMessage(message *models.OutboxMessage) error {
+	return c.DB.Create(message).Error
+}
+
+// ClaimOutboxMessages marks up to limit messages as being sent and returns
+// them. Messages are claimable when they are pending and weren't put off past
+// now, or when a dispatcher claimed them before leaseExpiredBefore and never
+// finished. A message is only ever claimed by one dispatcher at a time.
+func (c *Client) ClaimOutboxMessages(leaseExpiredBefore time.Time, limit int) ([]*models.OutboxMessage, error) {
+	now := time.Now()
+	claimable := func(db *gorm.DB) *gorm.DB {
+		return db.Where(
+			"(status = ? AND (available_at IS NULL OR available_at <= ?)) OR (status = ? AND claimed_at < ?)",
+			models.OutboxStatusPending, now,
+			models.OutboxStatusSending, leaseExpiredBefore,
+		)
+	}
+
+	var candidates []*models.OutboxMessage
+	err := c.DB.Scopes(claimable).Order("id ASC").Limit(limit).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []*models.OutboxMessage
+	for _, message := range candidates {
+		result := c.DB.Model(&models.OutboxMessage{}).
+			Scopes(claimable).
+			Where("id = ?", message.ID).
+			Updates(map[string]any{"status": models.OutboxStatusSending, "claimed_at": now})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		// Another dispatcher got to it first
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		message.Status = models.OutboxStatusSending
+		message.ClaimedAt = &now
+		claimed = append(claimed, message)
+	}
+
+	return claimed, nil
+}
+
+func (c *Client) MarkOutboxMessageSent(messageID uint, sentMessageID int) error {
+	return c.DB.Model(&models.OutboxMessage{}).
+		Where("id = ?", messageID).
+		Updates(map[string]any{
+			"status":          models.OutboxStatusSent,
+			"sent_message_id": sentMessageID,
+			"sent_at":         time.Now(),
+			"attempts":        gorm.Expr("attempts + 1"),
+		}).
+		Error
+}
+
+// MarkOutboxMessageFailed records a failed attempt, putting the message back
+// in line for retryAt unless it was the last one.
+func (c *Client) MarkOutboxMessageFailed(messageID uint, sendErr error, final bool, retryAt time.Time) error {
+	status := models.OutboxStatusPending
+	if final {
+		status = models.OutboxStatusFailed
+	}
+
+	return c.DB.Model(&models.OutboxMessage{}).
+		Where("id = ?", messageID).
+		Updates(map[string]any{
+			"status":       status,
+			"last_error":   sendErr.Error(),
+			"attempts":     gorm.Expr("attempts + 1"),
+			"claimed_at":   nil,
+			"available_at": retryAt,
+		}).
+		Error
+}
+
+// DeferOutboxMessage releases the claim on the message and puts it off until
+// the given time, without counting it as an attempt.
+func (c *Client) DeferOutboxMessage(messageID uint, until time.Time) error {
+	return c.DB.Model(&models.OutboxMessage{}).
+		Where("id = ?", messageID).
+		Updates(map[string]any{
+			"status":       models.OutboxStatusPending,
+			"claimed_at":   nil,
+			"available_at": until,
+		}).
+		Error
+}
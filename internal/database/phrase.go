@@ -2,6 +2,7 @@ package database
 
 import (
 	"github.com/kiasaty/phrase-mate/models"
+	"gorm.io/gorm"
 )
 
 func (c *Client) CreatePhrase(phrase *models.Phrase) (*models.Phrase, error) {
@@ -11,9 +12,12 @@ func (c *Client) CreatePhrase(phrase *models.Phrase) (*models.Phrase, error) {
 	return phrase, nil
 }
 
-func (c *Client) FindPhraseByMessageId(messageID int) (phrase *models.Phrase) {
+func (c *Client) FindPhraseByMessageId(chatID int64, messageID int) (phrase *models.Phrase) {
 	var p models.Phrase
-	if err := c.DB.Preload("User").Preload("Tags").Where("telegram_message_id = ?", messageID).First(&p).Error; err != nil {
+	err := c.DB.Preload("User").Preload("Tags").
+		Where("telegram_chat_id = ? AND telegram_message_id = ?", chatID, messageID).
+		First(&p).Error
+	if err != nil {
 		return nil
 	}
 	return &p
@@ -33,7 +37,7 @@ func (c *Client) UpdatePhraseTags(phrase *models.Phrase, tags *[]models.Tag) err
 func (c *Client) FindPhrase(phraseID uint) (*models.Phrase, error) {
 	var phrase models.Phrase
 
-	result := c.DB.First(&phrase, phraseID)
+	result := c.DB.Preload("Tags").First(&phrase, phraseID)
 
 	if result.Error != nil {
 		return nil, result.Error
@@ -50,10 +54,16 @@ func (c *Client) FindNewPhrasesToReview(userID uint, limit int) ([]uint, error)
 		FROM phrases
 		LEFT JOIN reviews ON phrases.id = reviews.phrase_id AND reviews.user_id = ?
 		WHERE reviews.id IS NULL
+		AND (
+			(phrases.user_id = ? AND phrases.deck_id IS NULL)
+			OR phrases.deck_id IN (SELECT deck_id FROM deck_members WHERE user_id = ?)
+		)
 		AND phrases.is_mastered = false
+		AND phrases.is_suspended = false
+		AND phrases.deleted_at IS NULL
 		LIMIT ?
 	`
-	err := c.DB.Raw(query, userID, limit).Scan(&phraseIDs).Error
+	err := c.DB.Raw(query, userID, userID, userID, limit).Scan(&phraseIDs).Error
 	if err != nil {
 		return nil, err
 	}
@@ -67,3 +77,67 @@ func (c *Client) MarkPhraseAsMastered(phraseID uint) error {
 		Update("is_mastered", true).
 		Error
 }
+
+func (c *Client) UnmarkPhraseAsMastered(phraseID uint) error {
+	return c.DB.Model(&models.Phrase{}).
+		Where("id = ?", phraseID).
+		Update("is_mastered", false).
+		Error
+}
+
+func (c *Client) SetPhraseSuspended(phraseID uint, suspended bool) error {
+	return c.DB.Model(&models.Phrase{}).
+		Where("id = ?", phraseID).
+		Update("is_suspended", suspended).
+		Error
+}
+
+func (c *Client) DeletePhrase(phraseID uint) error {
+	return c.DB.Delete(&models.Phrase{}, phraseID).Error
+}
+
+// FindRelatedPhrases returns random phrases of the user, or shared with them,
+// that carry at least one tag of the given phrase.
+func (c *Client) FindRelatedPhrases(userID uint, phraseID uint, limit int) ([]*models.Phrase, error) {
+	var phrases []*models.Phrase
+
+	err := c.DB.
+		Where("phrases.id <> ?", phraseID).
+		Where(
+			"(phrases.user_id = ? OR phrases.deck_id IN (SELECT deck_id FROM deck_members WHERE user_id = ?))",
+			userID,
+			userID,
+		).
+		Where(
+			"phrases.id IN (SELECT phrase_id FROM phrase_tag WHERE tag_id IN (SELECT tag_id FROM phrase_tag WHERE phrase_id = ?))",
+			phraseID,
+		).
+		Order("RANDOM()").
+		Limit(limit).
+		Find(&phrases).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return phrases, nil
+}
+
+// migratePhraseChatIDs moves old databases from phrases keyed by the message
+// ID alone to the chat and message ID pair, filling in the chat the phrase was
+// posted in.
+func migratePhraseChatIDs(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&models.Phrase{}, "idx_phrases_telegram_message_id") {
+		if err := db.Migrator().DropIndex(&models.Phrase{}, "idx_phrases_telegram_message_id"); err != nil {
+			return err
+		}
+	}
+
+	return db.Exec(`
+		UPDATE phrases SET telegram_chat_id = COALESCE(
+			(SELECT decks.telegram_chat_id FROM decks WHERE decks.id = phrases.deck_id),
+			(SELECT users.telegram_chat_id FROM users WHERE users.id = phrases.user_id)
+		)
+		WHERE telegram_chat_id = 0
+	`).Error
+}
@@ -76,8 +76,9 @@ func (c *Client) GetDueReview(userID uint, now time.Time, limit uint) (*models.R
 	var review models.Review
 
 	err := c.DB.
-		Where("user_id = ? AND next_review_at <= ?", userID, now).
-		Order("next_review_at ASC, ease_factor ASC").
+		Scopes(reviewablePhrases(userID)).
+		Where("reviews.user_id = ? AND reviews.next_review_at <= ?", userID, now).
+		Order("reviews.next_review_at ASC, reviews.ease_factor ASC").
 		Limit(int(limit)).
 		First(&review).Error
 
@@ -92,6 +93,79 @@ func (c *Client) GetDueReview(userID uint, now time.Time, limit uint) (*models.R
 	return &review, nil
 }
 
+func (c *Client) CountDueReviews(userID uint, until time.Time) (uint, error) {
+	var count int64
+
+	err := c.DB.Model(&models.Review{}).
+		Scopes(reviewablePhrases(userID)).
+		Where("reviews.user_id = ? AND reviews.next_review_at <= ?", userID, until).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(count), nil
+}
+
+// CountReviewsDueBetween counts the reviews falling due from from on, until
+// but not including until.
+func (c *Client) CountReviewsDueBetween(userID uint, from time.Time, until time.Time) (uint, error) {
+	var count int64
+
+	err := c.DB.Model(&models.Review{}).
+		Scopes(reviewablePhrases(userID)).
+		Where("reviews.user_id = ? AND reviews.next_review_at >= ? AND reviews.next_review_at < ?", userID, from, until).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(count), nil
+}
+
+func (c *Client) FindDueReviews(userID uint, until time.Time, limit int) ([]*models.Review, error) {
+	var reviews []*models.Review
+
+	err := c.DB.
+		Scopes(reviewablePhrases(userID)).
+		Where("reviews.user_id = ? AND reviews.next_review_at <= ?", userID, until).
+		Order("reviews.next_review_at ASC, reviews.ease_factor ASC").
+		Limit(limit).
+		Find(&reviews).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (c *Client) DeleteReview(userID, phraseID uint) error {
+	return c.DB.Where("phrase_id = ? AND user_id = ?", phraseID, userID).
+		Delete(&models.Review{}).Error
+}
+
+// reviewablePhrases limits a reviews query to phrases that are still in
+// rotation, i.e. neither mastered, suspended nor deleted, and that the user
+// can still see, i.e. their own or shared through a deck they are in.
+func reviewablePhrases(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins(
+			"JOIN phrases ON phrases.id = reviews.phrase_id "+
+				"AND phrases.is_mastered = false "+
+				"AND phrases.is_suspended = false "+
+				"AND phrases.deleted_at IS NULL "+
+				"AND ("+
+				"(phrases.user_id = ? AND phrases.deck_id IS NULL) "+
+				"OR phrases.deck_id IN (SELECT deck_id FROM deck_members WHERE user_id = ?)"+
+				")",
+			userID, userID,
+		)
+	}
+}
+
 func (c *Client) CreateReviewHistory(review *models.ReviewHistory) error {
 	return c.DB.Create(review).Error
 }
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/kiasaty/phrase-mate/models"
+	"gorm.io/gorm"
+)
+
+// SearchPhrases finds the user's phrases, including the ones shared with them
+// through decks, matching the query, optionally narrowed down to the ones
+// carrying the given tag.
+func (c *Client) SearchPhrases(userID uint, query string, tagName string, offset, limit int) ([]*models.Phrase, uint, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where(
+			"(phrases.user_id = ? OR phrases.deck_id IN (SELECT deck_id FROM deck_members WHERE user_id = ?))",
+			userID,
+			userID,
+		)
+
+		if query != "" {
+			condition, argument := searchCondition(query)
+			db = db.Where(condition, argument)
+		}
+
+		if tagName != "" {
+			db = db.Where(
+				"phrases.id IN (SELECT phrase_tag.phrase_id FROM phrase_tag JOIN tags ON tags.id = phrase_tag.tag_id WHERE tags.name = ?)",
+				strings.ToLower(tagName),
+			)
+		}
+
+		return db
+	}
+
+	var total int64
+	if err := c.DB.Model(&models.Phrase{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var phrases []*models.Phrase
+	err := c.DB.Scopes(scope).
+		Preload("Tags").
+		Order("phrases.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&phrases).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return phrases, uint(total), nil
+}
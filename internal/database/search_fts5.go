@@ -0,0 +1,53 @@
+//go:build sqlite_fts5 || fts5
+
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SearchBackend names how phrases are searched in this build.
+const SearchBackend = "fts5"
+
+// migrateSearchIndex creates the FTS5 index over phrases.text and the
+// triggers keeping it in sync with the phrases table.
+func migrateSearchIndex(db *gorm.DB) error {
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS phrases_fts USING fts5(text, content='phrases', content_rowid='id')`,
+		`CREATE TRIGGER IF NOT EXISTS phrases_fts_insert AFTER INSERT ON phrases BEGIN
+			INSERT INTO phrases_fts(rowid, text) VALUES (new.id, new.text);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS phrases_fts_delete AFTER DELETE ON phrases BEGIN
+			INSERT INTO phrases_fts(phrases_fts, rowid, text) VALUES ('delete', old.id, old.text);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS phrases_fts_update AFTER UPDATE OF text ON phrases BEGIN
+			INSERT INTO phrases_fts(phrases_fts, rowid, text) VALUES ('delete', old.id, old.text);
+			INSERT INTO phrases_fts(rowid, text) VALUES (new.id, new.text);
+		END`,
+		`INSERT INTO phrases_fts(phrases_fts) VALUES ('rebuild')`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func searchCondition(query string) (string, interface{}) {
+	return "phrases.id IN (SELECT rowid FROM phrases_fts WHERE phrases_fts MATCH ?)", toMatchExpression(query)
+}
+
+// toMatchExpression quotes every term of the query so user input can't be
+// interpreted as FTS5 syntax, and lets each term match as a prefix.
+func toMatchExpression(query string) string {
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,24 @@
+//go:build !sqlite_fts5 && !fts5
+
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SearchBackend names how phrases are searched in this build, build with
+// -tags sqlite_fts5 for the full-text index.
+const SearchBackend = "like"
+
+// migrateSearchIndex is a no-op when SQLite is built without FTS5; searching
+// falls back to a LIKE scan over phrases.text.
+func migrateSearchIndex(db *gorm.DB) error {
+	return nil
+}
+
+func searchCondition(query string) (string, interface{}) {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return `phrases.text LIKE ? ESCAPE '\'`, "%" + replacer.Replace(query) + "%"
+}
@@ -0,0 +1,38 @@
+package database
+
+import "github.com/kiasaty/phrase-mate/models"
+
+func (c *Client) CreateSentMessage(message *models.SentMessage) error {
+	return c.DB.Create(message).Error
+}
+
+// FindSessionMessagesWithKeyboard returns the messages sent in the session
+// that still have a keyboard.
+func (c *Client) FindSessionMessagesWithKeyboard(sessionID uint) ([]*models.SentMessage, error) {
+	var messages []*models.SentMessage
+
+	err := c.DB.Where("session_id = ? AND has_keyboard = ?", sessionID, true).
+		Order("id ASC").
+		Find(&messages).Error
+
+	return messages, err
+}
+
+// FindPhraseMessagesWithKeyboard returns the messages the phrase was sent as
+// to the chat that still have a keyboard.
+func (c *Client) FindPhraseMessagesWithKeyboard(chatID int64, phraseID uint) ([]*models.SentMessage, error) {
+	var messages []*models.SentMessage
+
+	err := c.DB.Where("chat_id = ? AND phrase_id = ? AND has_keyboard = ?", chatID, phraseID, true).
+		Order("id ASC").
+		Find(&messages).Error
+
+	return messages, err
+}
+
+func (c *Client) MarkSentMessageKeyboardRemoved(chatID int64, messageID int) error {
+	return c.DB.Model(&models.SentMessage{}).
+		Where("chat_id = ? AND message_id = ?", chatID, messageID).
+		Update("has_keyboard", false).
+		Error
+}
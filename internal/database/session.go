@@ -14,11 +14,23 @@ func (c *Client) CreateSession(session *models.Session) (*models.Session, error)
 	return session, nil
 }
 
-func (c *Client) EndSession(sessionID uint) error {
+func (c *Client) EndSession(sessionID uint, reason models.SessionEndReason, stats *models.SessionStats) error {
 	now := time.Now()
 	return c.DB.Model(&models.Session{}).
 		Where("id = ?", sessionID).
-		Update("ended_at", now).Error
+		Updates(map[string]interface{}{
+			"ended_at":        now,
+			"end_reason":      reason,
+			"reviewed_count":  stats.ReviewedCount,
+			"average_quality": stats.AverageQuality,
+		}).Error
+}
+
+func (c *Client) TouchSession(sessionID uint) error {
+	now := time.Now()
+	return c.DB.Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		Update("last_activity_at", now).Error
 }
 
 func (c *Client) FindActiveSession(userID uint) (*models.Session, error) {
@@ -35,3 +47,56 @@ func (c *Client) FindActiveSession(userID uint) (*models.Session, error) {
 
 	return &session, nil
 }
+
+// FindIdleSessions returns the open sessions that saw no activity since
+// lastActiveBefore.
+func (c *Client) FindIdleSessions(lastActiveBefore time.Time) ([]*models.Session, error) {
+	var sessions []*models.Session
+
+	err := c.DB.
+		Where("ended_at IS NULL AND COALESCE(last_activity_at, started_at) < ?", lastActiveBefore).
+		Order("id ASC").
+		Find(&sessions).Error
+
+	return sessions, err
+}
+
+func (c *Client) FindSession(sessionID uint) (*models.Session, error) {
+	var session models.Session
+
+	if err := c.DB.First(&session, sessionID).Error; err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (c *Client) FindRecentSessions(userID uint, limit int) ([]*models.Session, error) {
+	var sessions []*models.Session
+
+	err := c.DB.Where("user_id = ?", userID).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&sessions).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (c *Client) GetSessionStats(sessionID uint) (*models.SessionStats, error) {
+	var stats models.SessionStats
+
+	err := c.DB.Model(&models.ReviewHistory{}).
+		Select("COUNT(*) AS reviewed_count, COALESCE(AVG(recall_quality), 0) AS average_quality").
+		Where("session_id = ?", sessionID).
+		Scan(&stats).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
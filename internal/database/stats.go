@@ -0,0 +1,35 @@
+package database
+
+import (
+	"time"
+
+	"github.com/kiasaty/phrase-mate/models"
+	"gorm.io/gorm"
+)
+
+func (c *Client) GetStats() (*models.Stats, error) {
+	var stats models.Stats
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	counts := []struct {
+		query *gorm.DB
+		count *int64
+	}{
+		{c.DB.Model(&models.User{}), &stats.Users},
+		{c.DB.Model(&models.User{}).Where("is_blocked = ?", true), &stats.BlockedUsers},
+		{c.DB.Model(&models.Phrase{}), &stats.Phrases},
+		{c.DB.Model(&models.ReviewHistory{}), &stats.Reviews},
+		{c.DB.Model(&models.ReviewHistory{}).Where("reviewed_at >= ?", startOfDay), &stats.ReviewsToday},
+		{c.DB.Model(&models.Session{}).Where("ended_at IS NULL"), &stats.ActiveSessions},
+	}
+
+	for _, count := range counts {
+		if err := count.query.Count(count.count).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &stats, nil
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/kiasaty/phrase-mate/models"
+	"gorm.io/gorm/clause"
 )
 
 func (c *Client) CreateTag(tag *models.Tag) (*models.Tag, error) {
@@ -24,3 +25,26 @@ func (c *Client) FindTagByName(name string) (*models.Tag, error) {
 	}
 	return &tag, nil
 }
+
+// SetUserTagTTSEnabled turns voice notes on or off for the user's phrases
+// filed under the tag.
+func (c *Client) SetUserTagTTSEnabled(userID uint, tagID uint, enabled bool) error {
+	setting := &models.UserTagSetting{UserID: userID, TagID: tagID, TTSEnabled: enabled}
+
+	return c.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "tag_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tts_enabled"}),
+	}).Create(setting).Error
+}
+
+// FindTTSEnabledTagIDs returns the tags the user turned voice notes on for.
+func (c *Client) FindTTSEnabledTagIDs(userID uint) ([]uint, error) {
+	var tagIDs []uint
+
+	err := c.DB.Model(&models.UserTagSetting{}).
+		Where("user_id = ? AND tts_enabled = ?", userID, true).
+		Pluck("tag_id", &tagIDs).
+		Error
+
+	return tagIDs, err
+}
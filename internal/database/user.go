@@ -12,6 +12,21 @@ func (c *Client) CreateUser(user *models.User) (*models.User, error) {
 	return user, nil
 }
 
+func (c *Client) FindUser(userID uint) (*models.User, error) {
+	var user models.User
+
+	err := c.DB.First(&user, userID).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 func (c *Client) FindUserByTelegramID(telegramID int64) (*models.User, error) {
 	var user models.User
 
@@ -27,6 +42,21 @@ func (c *Client) FindUserByTelegramID(telegramID int64) (*models.User, error) {
 	return &user, nil
 }
 
+func (c *Client) FindUserByUsername(username string) (*models.User, error) {
+	var user models.User
+
+	err := c.DB.Where("LOWER(username) = LOWER(?)", username).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 func (c *Client) GetAllUsers() ([]*models.User, error) {
 	var users []*models.User
 	if err := c.DB.Find(&users).Error; err != nil {
@@ -34,3 +64,38 @@ func (c *Client) GetAllUsers() ([]*models.User, error) {
 	}
 	return users, nil
 }
+
+func (c *Client) SetUserTTSEnabled(userID uint, enabled bool) error {
+	return c.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("tts_enabled", enabled).
+		Error
+}
+
+func (c *Client) SetUserReviewMode(userID uint, mode models.ReviewMode) error {
+	return c.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("review_mode", mode).
+		Error
+}
+
+func (c *Client) SetUserLanguage(userID uint, language string) error {
+	return c.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("language", language).
+		Error
+}
+
+func (c *Client) SetUserBlocked(userID uint, blocked bool) error {
+	return c.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("is_blocked", blocked).
+		Error
+}
+
+func (c *Client) SetUserStatus(userID uint, status models.UserStatus) error {
+	return c.DB.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("status", status).
+		Error
+}
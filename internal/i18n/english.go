@@ -0,0 +1,28 @@
+package i18n
+
+import "golang.org/x/text/feature/plural"
+
+// english only holds the messages that depend on a count, the rest are their
+// own translation.
+var english = translations{
+	"%d members": plural.Selectf(1, "%d",
+		plural.One, "%d member",
+		plural.Other, "%d members",
+	),
+	"%d phrases are due tomorrow.": plural.Selectf(1, "%d",
+		plural.One, "%d phrase is due tomorrow.",
+		plural.Other, "%d phrases are due tomorrow.",
+	),
+	"You reviewed %d phrases.": plural.Selectf(1, "%d",
+		plural.One, "You reviewed %d phrase.",
+		plural.Other, "You reviewed %d phrases.",
+	),
+	"Found %d phrases (page %d/%d):": plural.Selectf(1, "%d",
+		plural.One, "Found %d phrase (page %d/%d):",
+		plural.Other, "Found %d phrases (page %d/%d):",
+	),
+	"%s · quality %d · interval %d days": plural.Selectf(3, "%d",
+		plural.One, "%s · quality %d · interval %d day",
+		plural.Other, "%s · quality %d · interval %d days",
+	),
+}
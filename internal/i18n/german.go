@@ -0,0 +1,116 @@
+package i18n
+
+import "golang.org/x/text/feature/plural"
+
+var german = translations{
+	// Reviews
+	"Show answer":                     "Antwort zeigen",
+	"Choose the right answer:":        "Wähle die richtige Antwort:",
+	"Type your answer:":               "Tippe deine Antwort:",
+	"Review successfully saved!":      "Bewertung gespeichert!",
+	"✅ Correct!":                      "✅ Richtig!",
+	"✅ Correct: %s":                   "✅ Richtig: %s",
+	"❌ Wrong, the answer is: %s":      "❌ Falsch, die Antwort ist: %s",
+	"%.0f%% match, the answer is: %s": "%.0f%% Übereinstimmung, die Antwort ist: %s",
+
+	// Sessions
+	"Session finished!": "Sitzung beendet!",
+	"You reviewed %d phrases.": plural.Selectf(1, "%d",
+		plural.One, "Du hast %d Phrase wiederholt.",
+		plural.Other, "Du hast %d Phrasen wiederholt.",
+	),
+	"Average recall quality: %.1f": "Durchschnittliche Erinnerungsqualität: %.1f",
+	"%d phrases are due tomorrow.": plural.Selectf(1, "%d",
+		plural.One, "Morgen ist %d Phrase fällig.",
+		plural.Other, "Morgen sind %d Phrasen fällig.",
+	),
+	"You have no review sessions yet.": "Du hast noch keine Wiederholungssitzungen.",
+	"Your recent sessions:":            "Deine letzten Sitzungen:",
+	" · %d reviewed · avg %.1f":        " · %d wiederholt · Ø %.1f",
+	"active":                           "aktiv",
+	"completed":                        "abgeschlossen",
+	"exhausted":                        "ausgeschöpft",
+	"timeout":                          "abgelaufen",
+	"forced":                           "beendet",
+
+	// Settings
+	"Usage: /tts on|off [#tag]":                                                            "Verwendung: /tts on|off [#tag]",
+	"Voice notes turned on for all your phrases.":                                          "Sprachnachrichten für alle deine Phrasen eingeschaltet.",
+	"Voice notes turned off for all your phrases.":                                         "Sprachnachrichten für alle deine Phrasen ausgeschaltet.",
+	"Voice notes turned on for %s.":                                                        "Sprachnachrichten für %s eingeschaltet.",
+	"Voice notes turned off for %s.":                                                       "Sprachnachrichten für %s ausgeschaltet.",
+	"Text-to-speech isn't configured on this bot yet, so no voice notes will be attached.": "Text-to-Speech ist für diesen Bot noch nicht eingerichtet, daher werden keine Sprachnachrichten angehängt.",
+	"Your review mode is %s.":                                                              "Dein Wiederholungsmodus ist %s.",
+	"Usage: /mode rate|quiz|typed":                                                         "Verwendung: /mode rate|quiz|typed",
+	"Review mode set to %s.":                                                               "Wiederholungsmodus auf %s gesetzt.",
+	"Two-sided phrases, written as \"front = back\", are asked as multiple choice questions.":   "Zweiseitige Phrasen, geschrieben als \"Vorderseite = Rückseite\", werden als Multiple-Choice-Fragen gestellt.",
+	"For two-sided phrases, written as \"front = back\", type the back when you get the front.": "Bei zweiseitigen Phrasen, geschrieben als \"Vorderseite = Rückseite\", tippst du die Rückseite ein, wenn du die Vorderseite bekommst.",
+	"The bot speaks %s to you.":                              "Der Bot spricht %s mit dir.",
+	"Usage: /language %s|auto":                               "Verwendung: /language %s|auto",
+	"The bot now speaks %s.":                                 "Der Bot spricht jetzt %s.",
+	"The bot now follows the language of your Telegram app.": "Der Bot folgt jetzt der Sprache deiner Telegram-App.",
+	"Cancelled.": "Abgebrochen.",
+
+	// Phrases
+	"Usage: /phrase <id>": "Verwendung: /phrase <id>",
+	"Phrase not found.":   "Phrase nicht gefunden.",
+	"Delete phrase %s?":   "Phrase %s löschen?",
+	"Phrase deleted.":     "Phrase gelöscht.",
+	"Phrase updated!":     "Phrase aktualisiert!",
+	"Send the tag to add to the phrase, e.g. #german, or /cancel.": "Sende den Tag, der zur Phrase hinzugefügt werden soll, z. B. #german, oder /cancel.",
+	"Added %s to phrase %s.":        "%s zu Phrase %s hinzugefügt.",
+	"Phrase %s":                     "Phrase %s",
+	"Status: mastered":              "Status: gemeistert",
+	"Status: suspended":             "Status: pausiert",
+	"Status: active":                "Status: aktiv",
+	"Next review: %s":               "Nächste Wiederholung: %s",
+	"Next review: not reviewed yet": "Nächste Wiederholung: noch nicht wiederholt",
+	"History:":                      "Verlauf:",
+	"%s · quality %d · interval %d days": plural.Selectf(3, "%d",
+		plural.One, "%s · Qualität %d · Intervall %d Tag",
+		plural.Other, "%s · Qualität %d · Intervall %d Tage",
+	),
+	"Add tag":          "Tag hinzufügen",
+	"Suspend":          "Pausieren",
+	"Unsuspend":        "Fortsetzen",
+	"Unmaster":         "Wieder wiederholen",
+	"Reset scheduling": "Planung zurücksetzen",
+	"Delete":           "Löschen",
+
+	// Search
+	"Usage: /search <query> [#tag]": "Verwendung: /search <query> [#tag]",
+	"No phrases found.":             "Keine Phrasen gefunden.",
+	"Found %d phrases (page %d/%d):": plural.Selectf(1, "%d",
+		plural.One, "%d Phrase gefunden (Seite %d/%d):",
+		plural.Other, "%d Phrasen gefunden (Seite %d/%d):",
+	),
+	"Use /phrase <id> to manage a phrase.": "Mit /phrase <id> verwaltest du eine Phrase.",
+	"« Prev":                               "« Zurück",
+	"Next »":                               "Weiter »",
+
+	// Decks
+	"Usage:\n/deck new #tag - share your #tag phrases as a deck\n/deck leave <id> - leave a deck\n/decks - list your decks": "Verwendung:\n/deck new #tag - teile deine #tag-Phrasen als Deck\n/deck leave <id> - ein Deck verlassen\n/decks - deine Decks auflisten",
+	"You already share %s: %s": "Du teilst %s bereits: %s",
+	"Your %s phrases are now a shared deck. Invite others with this link:": "Deine %s-Phrasen sind jetzt ein geteiltes Deck. Lade andere mit diesem Link ein:",
+	"Deck not found.": "Deck nicht gefunden.",
+	"You own this deck, so you can't leave it.": "Dir gehört dieses Deck, daher kannst du es nicht verlassen.",
+	"You are not a member of this deck.":        "Du bist kein Mitglied dieses Decks.",
+	"You left %s.":                              "Du hast %s verlassen.",
+	"You are not in any deck yet.":              "Du bist noch in keinem Deck.",
+	"Your decks:":                               "Deine Decks:",
+	"%d members": plural.Selectf(1, "%d",
+		plural.One, "%d Mitglied",
+		plural.Other, "%d Mitglieder",
+	),
+	"invite: %s":                     "Einladung: %s",
+	"This invite link is not valid.": "Dieser Einladungslink ist ungültig.",
+	"You joined %s shared by %s. Its phrases will show up in your reviews.": "Du bist %s von %s beigetreten. Die Phrasen daraus erscheinen in deinen Wiederholungen.",
+
+	// Access
+	"Welcome! Send a message with a #hashtag to save your first phrase.":                                  "Willkommen! Sende eine Nachricht mit einem #Hashtag, um deine erste Phrase zu speichern.",
+	"This bot is invite-only. Start it with /start <invite-code>.":                                        "Dieser Bot ist nur auf Einladung nutzbar. Starte ihn mit /start <invite-code>.",
+	"Your request to use the bot is waiting for an admin's approval.":                                     "Deine Anfrage, den Bot zu nutzen, wartet auf die Freigabe durch einen Admin.",
+	"Your request to use the bot was sent to the admins. You'll get a message once it's approved.":        "Deine Anfrage, den Bot zu nutzen, wurde an die Admins geschickt. Du bekommst eine Nachricht, sobald sie angenommen ist.",
+	"Your request to use the bot was approved. Send a message with a #hashtag to save your first phrase.": "Deine Anfrage, den Bot zu nutzen, wurde angenommen. Sende eine Nachricht mit einem #Hashtag, um deine erste Phrase zu speichern.",
+	"Your request to use the bot was declined.":                                                           "Deine Anfrage, den Bot zu nutzen, wurde abgelehnt.",
+}
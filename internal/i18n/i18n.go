@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Languages are the languages the bot speaks, English comes first as the
+// fallback for the rest.
+var Languages = []language.Tag{
+	language.English,
+	language.German,
+	language.Persian,
+	language.Spanish,
+}
+
+// translations maps the English messages, which double as the keys, to their
+// translation. A translation is either a string or a catalog.Message, like
+// plural.Selectf for the messages that depend on a count.
+type translations map[string]any
+
+// catalogs holds the translations of every language. English only lists the
+// messages with plurals, the rest are printed as they are.
+var catalogs = map[language.Tag]translations{
+	language.English: english,
+	language.German:  german,
+	language.Persian: persian,
+	language.Spanish: spanish,
+}
+
+var (
+	matcher = language.NewMatcher(Languages)
+	builder = newCatalog()
+)
+
+func newCatalog() *catalog.Builder {
+	builder := catalog.NewBuilder(catalog.Fallback(language.English))
+
+	for tag, translations := range catalogs {
+		for key, translation := range translations {
+			var err error
+			switch translation := translation.(type) {
+			case string:
+				err = builder.SetString(tag, key, translation)
+			case catalog.Message:
+				err = builder.Set(tag, key, translation)
+			default:
+				err = fmt.Errorf("unsupported translation type %T", translation)
+			}
+			if err != nil {
+				panic(fmt.Sprintf("i18n: translating %q to %s: %v", key, tag, err))
+			}
+		}
+	}
+
+	return builder
+}
+
+// Match returns the language of Languages closest to the language code, like
+// the one Telegram reports for a user, and English when none is close.
+func Match(code string) language.Tag {
+	tag, err := language.Parse(code)
+	if err != nil {
+		return language.English
+	}
+
+	_, index, confidence := matcher.Match(tag)
+	if confidence == language.No {
+		return language.English
+	}
+
+	return Languages[index]
+}
+
+// IsSupported tells whether the language code names one of Languages.
+func IsSupported(code string) bool {
+	tag, err := language.Parse(code)
+	if err != nil {
+		return false
+	}
+
+	base, _ := tag.Base()
+	for _, supported := range Languages {
+		if supportedBase, _ := supported.Base(); supportedBase == base {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Codes returns the language codes of Languages.
+func Codes() []string {
+	codes := make([]string, len(Languages))
+	for i, tag := range Languages {
+		codes[i] = tag.String()
+	}
+	return codes
+}
+
+// Name returns the name of the language closest to the language code, in that
+// language.
+func Name(code string) string {
+	return display.Self.Name(Match(code))
+}
+
+// Printer returns the printer of the messages in the language closest to the
+// language code.
+func Printer(code string) *message.Printer {
+	return message.NewPrinter(Match(code), message.Catalog(builder))
+}
@@ -0,0 +1,57 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestEveryLanguageTranslatesTheSameMessages(t *testing.T) {
+	for _, tag := range []language.Tag{language.Persian, language.Spanish} {
+		for key := range german {
+			assert.Contains(t, catalogs[tag], key, "%s misses a translation", tag)
+		}
+		for key := range catalogs[tag] {
+			assert.Contains(t, german, key, "%s has an unknown message", tag)
+		}
+	}
+
+	for key := range english {
+		assert.Contains(t, german, key)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	assert.Equal(t, language.English, Match("en"))
+	assert.Equal(t, language.German, Match("de"))
+	assert.Equal(t, language.German, Match("de-AT"))
+	assert.Equal(t, language.Persian, Match("fa"))
+	assert.Equal(t, language.Spanish, Match("es-419"))
+	assert.Equal(t, language.English, Match("ja"))
+	assert.Equal(t, language.English, Match(""))
+	assert.Equal(t, language.English, Match("not a language"))
+}
+
+func TestIsSupported(t *testing.T) {
+	assert.True(t, IsSupported("de"))
+	assert.True(t, IsSupported("es-MX"))
+	assert.False(t, IsSupported("ja"))
+	assert.False(t, IsSupported(""))
+	assert.Equal(t, []string{"en", "de", "fa", "es"}, Codes())
+}
+
+func TestPrinterTranslatesAndFallsBackToEnglish(t *testing.T) {
+	assert.Equal(t, "Antwort zeigen", Printer("de").Sprintf("Show answer"))
+	assert.Equal(t, "Mostrar respuesta", Printer("es").Sprintf("Show answer"))
+	assert.Equal(t, "Show answer", Printer("ja").Sprintf("Show answer"))
+	assert.Equal(t, "Not translated", Printer("de").Sprintf("Not translated"))
+}
+
+func TestPrinterPluralizes(t *testing.T) {
+	assert.Equal(t, "You reviewed 1 phrase.", Printer("en").Sprintf("You reviewed %d phrases.", 1))
+	assert.Equal(t, "You reviewed 3 phrases.", Printer("en").Sprintf("You reviewed %d phrases.", 3))
+	assert.Equal(t, "Morgen ist 1 Phrase fällig.", Printer("de").Sprintf("%d phrases are due tomorrow.", 1))
+	assert.Equal(t, "Morgen sind 2 Phrasen fällig.", Printer("de").Sprintf("%d phrases are due tomorrow.", 2))
+	assert.Equal(t, "2026-10-19 · calidad 4 · intervalo 1 día", Printer("es").Sprintf("%s · quality %d · interval %d days", "2026-10-19", 4, 1))
+}
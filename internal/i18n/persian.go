@@ -0,0 +1,100 @@
+package i18n
+
+// persian has no plurals, nouns stay singular after a number.
+var persian = translations{
+	// Reviews
+	"Show answer":                     "نمایش پاسخ",
+	"Choose the right answer:":        "پاسخ درست را انتخاب کنید:",
+	"Type your answer:":               "پاسخ خود را تایپ کنید:",
+	"Review successfully saved!":      "مرور با موفقیت ذخیره شد!",
+	"✅ Correct!":                      "✅ درست است!",
+	"✅ Correct: %s":                   "✅ درست: %s",
+	"❌ Wrong, the answer is: %s":      "❌ نادرست، پاسخ: %s",
+	"%.0f%% match, the answer is: %s": "%.0f%% مطابقت، پاسخ: %s",
+
+	// Sessions
+	"Session finished!":                "جلسه تمام شد!",
+	"You reviewed %d phrases.":         "%d عبارت را مرور کردید.",
+	"Average recall quality: %.1f":     "میانگین کیفیت یادآوری: %.1f",
+	"%d phrases are due tomorrow.":     "فردا %d عبارت برای مرور دارید.",
+	"You have no review sessions yet.": "هنوز هیچ جلسهٔ مروری ندارید.",
+	"Your recent sessions:":            "جلسه‌های اخیر شما:",
+	" · %d reviewed · avg %.1f":        " · %d مرورشده · میانگین %.1f",
+	"active":                           "فعال",
+	"completed":                        "کامل‌شده",
+	"exhausted":                        "بدون عبارت",
+	"timeout":                          "منقضی‌شده",
+	"forced":                           "متوقف‌شده",
+
+	// Settings
+	"Usage: /tts on|off [#tag]":                                                            "استفاده: /tts on|off [#tag]",
+	"Voice notes turned on for all your phrases.":                                          "پیام‌های صوتی برای همهٔ عبارت‌های شما روشن شد.",
+	"Voice notes turned off for all your phrases.":                                         "پیام‌های صوتی برای همهٔ عبارت‌های شما خاموش شد.",
+	"Voice notes turned on for %s.":                                                        "پیام‌های صوتی برای %s روشن شد.",
+	"Voice notes turned off for %s.":                                                       "پیام‌های صوتی برای %s خاموش شد.",
+	"Text-to-speech isn't configured on this bot yet, so no voice notes will be attached.": "تبدیل متن به گفتار هنوز روی این ربات تنظیم نشده، پس پیام صوتی پیوست نمی‌شود.",
+	"Your review mode is %s.":                                                              "حالت مرور شما %s است.",
+	"Usage: /mode rate|quiz|typed":                                                         "استفاده: /mode rate|quiz|typed",
+	"Review mode set to %s.":                                                               "حالت مرور روی %s تنظیم شد.",
+	"Two-sided phrases, written as \"front = back\", are asked as multiple choice questions.":   "عبارت‌های دوطرفه که به شکل «رو = پشت» نوشته شده‌اند، به صورت پرسش چندگزینه‌ای پرسیده می‌شوند.",
+	"For two-sided phrases, written as \"front = back\", type the back when you get the front.": "برای عبارت‌های دوطرفه که به شکل «رو = پشت» نوشته شده‌اند، وقتی رو را دیدید پشت آن را تایپ کنید.",
+	"The bot speaks %s to you.":                              "ربات با شما %s صحبت می‌کند.",
+	"Usage: /language %s|auto":                               "استفاده: /language %s|auto",
+	"The bot now speaks %s.":                                 "ربات اکنون %s صحبت می‌کند.",
+	"The bot now follows the language of your Telegram app.": "ربات اکنون از زبان برنامهٔ تلگرام شما پیروی می‌کند.",
+	"Cancelled.": "لغو شد.",
+
+	// Phrases
+	"Usage: /phrase <id>": "استفاده: /phrase <id>",
+	"Phrase not found.":   "عبارت پیدا نشد.",
+	"Delete phrase %s?":   "عبارت %s حذف شود؟",
+	"Phrase deleted.":     "عبارت حذف شد.",
+	"Phrase updated!":     "عبارت به‌روز شد!",
+	"Send the tag to add to the phrase, e.g. #german, or /cancel.": "برچسبی را که می‌خواهید به عبارت اضافه شود بفرستید، مثلاً #german، یا /cancel.",
+	"Added %s to phrase %s.":             "%s به عبارت %s اضافه شد.",
+	"Phrase %s":                          "عبارت %s",
+	"Status: mastered":                   "وضعیت: یادگرفته‌شده",
+	"Status: suspended":                  "وضعیت: معلق",
+	"Status: active":                     "وضعیت: فعال",
+	"Next review: %s":                    "مرور بعدی: %s",
+	"Next review: not reviewed yet":      "مرور بعدی: هنوز مرور نشده",
+	"History:":                           "سابقه:",
+	"%s · quality %d · interval %d days": "%s · کیفیت %d · فاصله %d روز",
+	"Add tag":                            "افزودن برچسب",
+	"Suspend":                            "تعلیق",
+	"Unsuspend":                          "لغو تعلیق",
+	"Unmaster":                           "بازگشت به مرور",
+	"Reset scheduling":                   "بازنشانی زمان‌بندی",
+	"Delete":                             "حذف",
+
+	// Search
+	"Usage: /search <query> [#tag]":        "استفاده: /search <query> [#tag]",
+	"No phrases found.":                    "عبارتی پیدا نشد.",
+	"Found %d phrases (page %d/%d):":       "%d عبارت پیدا شد (صفحهٔ %d/%d):",
+	"Use /phrase <id> to manage a phrase.": "برای مدیریت یک عبارت از /phrase <id> استفاده کنید.",
+	"« Prev":                               "« قبلی",
+	"Next »":                               "بعدی »",
+
+	// Decks
+	"Usage:\n/deck new #tag - share your #tag phrases as a deck\n/deck leave <id> - leave a deck\n/decks - list your decks": "استفاده:\n/deck new #tag - عبارت‌های #tag خود را به صورت دسته به اشتراک بگذارید\n/deck leave <id> - ترک یک دسته\n/decks - فهرست دسته‌های شما",
+	"You already share %s: %s": "شما %s را از قبل به اشتراک گذاشته‌اید: %s",
+	"Your %s phrases are now a shared deck. Invite others with this link:": "عبارت‌های %s شما اکنون یک دستهٔ مشترک هستند. دیگران را با این لینک دعوت کنید:",
+	"Deck not found.": "دسته پیدا نشد.",
+	"You own this deck, so you can't leave it.": "این دسته متعلق به شماست، پس نمی‌توانید آن را ترک کنید.",
+	"You are not a member of this deck.":        "شما عضو این دسته نیستید.",
+	"You left %s.":                              "شما %s را ترک کردید.",
+	"You are not in any deck yet.":              "شما هنوز عضو هیچ دسته‌ای نیستید.",
+	"Your decks:":                               "دسته‌های شما:",
+	"%d members":                                "%d عضو",
+	"invite: %s":                                "دعوت: %s",
+	"This invite link is not valid.":            "این لینک دعوت معتبر نیست.",
+	"You joined %s shared by %s. Its phrases will show up in your reviews.": "شما به %s که %s به اشتراک گذاشته پیوستید. عبارت‌های آن در مرورهای شما نمایش داده می‌شوند.",
+
+	// Access
+	"Welcome! Send a message with a #hashtag to save your first phrase.":                                  "خوش آمدید! برای ذخیرهٔ اولین عبارت خود، پیامی با یک #هشتگ بفرستید.",
+	"This bot is invite-only. Start it with /start <invite-code>.":                                        "این ربات فقط با دعوت‌نامه در دسترس است. آن را با /start <invite-code> شروع کنید.",
+	"Your request to use the bot is waiting for an admin's approval.":                                     "درخواست شما برای استفاده از ربات در انتظار تأیید مدیر است.",
+	"Your request to use the bot was sent to the admins. You'll get a message once it's approved.":        "درخواست شما برای استفاده از ربات برای مدیران فرستاده شد. پس از تأیید به شما پیام می‌دهیم.",
+	"Your request to use the bot was approved. Send a message with a #hashtag to save your first phrase.": "درخواست شما برای استفاده از ربات تأیید شد. برای ذخیرهٔ اولین عبارت خود، پیامی با یک #هشتگ بفرستید.",
+	"Your request to use the bot was declined.":                                                           "درخواست شما برای استفاده از ربات رد شد.",
+}
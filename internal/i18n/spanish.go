@@ -0,0 +1,116 @@
+package i18n
+
+import "golang.org/x/text/feature/plural"
+
+var spanish = translations{
+	// Reviews
+	"Show answer":                     "Mostrar respuesta",
+	"Choose the right answer:":        "Elige la respuesta correcta:",
+	"Type your answer:":               "Escribe tu respuesta:",
+	"Review successfully saved!":      "¡Repaso guardado!",
+	"✅ Correct!":                      "✅ ¡Correcto!",
+	"✅ Correct: %s":                   "✅ Correcto: %s",
+	"❌ Wrong, the answer is: %s":      "❌ Incorrecto, la respuesta es: %s",
+	"%.0f%% match, the answer is: %s": "%.0f%% de coincidencia, la respuesta es: %s",
+
+	// Sessions
+	"Session finished!": "¡Sesión terminada!",
+	"You reviewed %d phrases.": plural.Selectf(1, "%d",
+		plural.One, "Repasaste %d frase.",
+		plural.Other, "Repasaste %d frases.",
+	),
+	"Average recall quality: %.1f": "Calidad media de recuerdo: %.1f",
+	"%d phrases are due tomorrow.": plural.Selectf(1, "%d",
+		plural.One, "Mañana toca repasar %d frase.",
+		plural.Other, "Mañana toca repasar %d frases.",
+	),
+	"You have no review sessions yet.": "Aún no tienes sesiones de repaso.",
+	"Your recent sessions:":            "Tus sesiones recientes:",
+	" · %d reviewed · avg %.1f":        " · %d repasadas · media %.1f",
+	"active":                           "activa",
+	"completed":                        "completada",
+	"exhausted":                        "agotada",
+	"timeout":                          "expirada",
+	"forced":                           "finalizada",
+
+	// Settings
+	"Usage: /tts on|off [#tag]":                                                            "Uso: /tts on|off [#tag]",
+	"Voice notes turned on for all your phrases.":                                          "Notas de voz activadas para todas tus frases.",
+	"Voice notes turned off for all your phrases.":                                         "Notas de voz desactivadas para todas tus frases.",
+	"Voice notes turned on for %s.":                                                        "Notas de voz activadas para %s.",
+	"Voice notes turned off for %s.":                                                       "Notas de voz desactivadas para %s.",
+	"Text-to-speech isn't configured on this bot yet, so no voice notes will be attached.": "La conversión de texto a voz aún no está configurada en este bot, así que no se adjuntarán notas de voz.",
+	"Your review mode is %s.":                                                              "Tu modo de repaso es %s.",
+	"Usage: /mode rate|quiz|typed":                                                         "Uso: /mode rate|quiz|typed",
+	"Review mode set to %s.":                                                               "Modo de repaso cambiado a %s.",
+	"Two-sided phrases, written as \"front = back\", are asked as multiple choice questions.":   "Las frases de dos caras, escritas como \"anverso = reverso\", se preguntan como preguntas de opción múltiple.",
+	"For two-sided phrases, written as \"front = back\", type the back when you get the front.": "En las frases de dos caras, escritas como \"anverso = reverso\", escribe el reverso cuando recibas el anverso.",
+	"The bot speaks %s to you.":                              "El bot te habla en %s.",
+	"Usage: /language %s|auto":                               "Uso: /language %s|auto",
+	"The bot now speaks %s.":                                 "El bot ahora habla %s.",
+	"The bot now follows the language of your Telegram app.": "El bot ahora sigue el idioma de tu app de Telegram.",
+	"Cancelled.": "Cancelado.",
+
+	// Phrases
+	"Usage: /phrase <id>": "Uso: /phrase <id>",
+	"Phrase not found.":   "Frase no encontrada.",
+	"Delete phrase %s?":   "¿Eliminar la frase %s?",
+	"Phrase deleted.":     "Frase eliminada.",
+	"Phrase updated!":     "¡Frase actualizada!",
+	"Send the tag to add to the phrase, e.g. #german, or /cancel.": "Envía la etiqueta que quieres añadir a la frase, p. ej. #german, o /cancel.",
+	"Added %s to phrase %s.":        "%s añadido a la frase %s.",
+	"Phrase %s":                     "Frase %s",
+	"Status: mastered":              "Estado: dominada",
+	"Status: suspended":             "Estado: suspendida",
+	"Status: active":                "Estado: activa",
+	"Next review: %s":               "Próximo repaso: %s",
+	"Next review: not reviewed yet": "Próximo repaso: aún sin repasar",
+	"History:":                      "Historial:",
+	"%s · quality %d · interval %d days": plural.Selectf(3, "%d",
+		plural.One, "%s · calidad %d · intervalo %d día",
+		plural.Other, "%s · calidad %d · intervalo %d días",
+	),
+	"Add tag":          "Añadir etiqueta",
+	"Suspend":          "Suspender",
+	"Unsuspend":        "Reanudar",
+	"Unmaster":         "Volver a repasar",
+	"Reset scheduling": "Reiniciar programación",
+	"Delete":           "Eliminar",
+
+	// Search
+	"Usage: /search <query> [#tag]": "Uso: /search <query> [#tag]",
+	"No phrases found.":             "No se encontraron frases.",
+	"Found %d phrases (page %d/%d):": plural.Selectf(1, "%d",
+		plural.One, "Se encontró %d frase (página %d/%d):",
+		plural.Other, "Se encontraron %d frases (página %d/%d):",
+	),
+	"Use /phrase <id> to manage a phrase.": "Usa /phrase <id> para gestionar una frase.",
+	"« Prev":                               "« Anterior",
+	"Next »":                               "Siguiente »",
+
+	// Decks
+	"Usage:\n/deck new #tag - share your #tag phrases as a deck\n/deck leave <id> - leave a deck\n/decks - list your decks": "Uso:\n/deck new #tag - comparte tus frases de #tag como mazo\n/deck leave <id> - sal de un mazo\n/decks - lista tus mazos",
+	"You already share %s: %s": "Ya compartes %s: %s",
+	"Your %s phrases are now a shared deck. Invite others with this link:": "Tus frases de %s ahora son un mazo compartido. Invita a otros con este enlace:",
+	"Deck not found.": "Mazo no encontrado.",
+	"You own this deck, so you can't leave it.": "Este mazo es tuyo, así que no puedes salir de él.",
+	"You are not a member of this deck.":        "No eres miembro de este mazo.",
+	"You left %s.":                              "Saliste de %s.",
+	"You are not in any deck yet.":              "Aún no estás en ningún mazo.",
+	"Your decks:":                               "Tus mazos:",
+	"%d members": plural.Selectf(1, "%d",
+		plural.One, "%d miembro",
+		plural.Other, "%d miembros",
+	),
+	"invite: %s":                     "invitación: %s",
+	"This invite link is not valid.": "Este enlace de invitación no es válido.",
+	"You joined %s shared by %s. Its phrases will show up in your reviews.": "Te uniste a %s, compartido por %s. Sus frases aparecerán en tus repasos.",
+
+	// Access
+	"Welcome! Send a message with a #hashtag to save your first phrase.":                                  "¡Bienvenido! Envía un mensaje con un #hashtag para guardar tu primera frase.",
+	"This bot is invite-only. Start it with /start <invite-code>.":                                        "Este bot es solo por invitación. Inícialo con /start <invite-code>.",
+	"Your request to use the bot is waiting for an admin's approval.":                                     "Tu solicitud para usar el bot está esperando la aprobación de un administrador.",
+	"Your request to use the bot was sent to the admins. You'll get a message once it's approved.":        "Tu solicitud para usar el bot se envió a los administradores. Recibirás un mensaje cuando sea aprobada.",
+	"Your request to use the bot was approved. Send a message with a #hashtag to save your first phrase.": "Tu solicitud para usar el bot fue aprobada. Envía un mensaje con un #hashtag para guardar tu primera frase.",
+	"Your request to use the bot was declined.":                                                           "Tu solicitud para usar el bot fue rechazada.",
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextAttributesAreLogged(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := New(&buffer, slog.LevelInfo)
+
+	ctx := With(context.Background(), "update_id", 7, "chat_id", int64(42))
+	ctx = With(ctx, "phrase_id", uint(3))
+
+	logger.InfoContext(ctx, "Phrase added", "tags", 2)
+	logger.DebugContext(ctx, "Not logged below the level")
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.Equal(t, "Phrase added", record["msg"])
+	assert.Equal(t, float64(7), record["update_id"])
+	assert.Equal(t, float64(42), record["chat_id"])
+	assert.Equal(t, float64(3), record["phrase_id"])
+	assert.Equal(t, float64(2), record["tags"])
+}
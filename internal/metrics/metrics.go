@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "phrase_mate"
+
+var (
+	PhrasesCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "phrases_created_total",
+		Help:      "Number of phrases saved from messages.",
+	})
+
+	Reviews = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviews_total",
+		Help:      "Number of reviews, by recall quality.",
+	}, []string{"recall_quality"})
+
+	SessionsStarted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_started_total",
+		Help:      "Number of review sessions started.",
+	})
+
+	SessionsEnded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_ended_total",
+		Help:      "Number of review sessions ended, by end reason.",
+	}, []string{"reason"})
+
+	TelegramErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_api_errors_total",
+		Help:      "Number of failed Telegram Bot API requests, by method.",
+	}, []string{"method"})
+
+	DatabaseErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "database_errors_total",
+		Help:      "Number of failed database queries.",
+	})
+
+	UpdateDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "update_handling_seconds",
+		Help:      "Time spent handling a Telegram update, by update type.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type"})
+)
+
+// ObserveUpdate records how long handling an update of the given type took
+// since start.
+func ObserveUpdate(updateType string, start time.Time) {
+	UpdateDuration.WithLabelValues(updateType).Observe(time.Since(start).Seconds())
+}
+
+// ObserveReview counts a review with the given recall quality.
+func ObserveReview(recallQuality uint8) {
+	Reviews.WithLabelValues(strconv.Itoa(int(recallQuality))).Inc()
+}
+
+// HTTPClient is the client interface the Telegram bot sends its requests with.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type telegramClient struct {
+	HTTPClient
+}
+
+// InstrumentTelegramClient wraps the client of the Telegram bot to count the
+// requests that fail or that the Bot API answers with an error status.
+func InstrumentTelegramClient(client HTTPClient) HTTPClient {
+	return &telegramClient{HTTPClient: client}
+}
+
+func (c *telegramClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		// The URL ends in the Bot API method, the token before it stays out of the labels
+		TelegramErrors.WithLabelValues(path.Base(req.URL.Path)).Inc()
+	}
+	return resp, err
+}
+
+// Handler serves the metrics of the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
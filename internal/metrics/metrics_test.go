@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type clientFunc func(req *http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestInstrumentTelegramClient(t *testing.T) {
+	statusCode := http.StatusOK
+	var err error
+	client := InstrumentTelegramClient(clientFunc(func(req *http.Request) (*http.Response, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: statusCode}, nil
+	}))
+
+	request := func() {
+		req, _ := http.NewRequest(http.MethodPost, "https://api.telegram.org/botTOKEN/sendMessage", nil)
+		client.Do(req)
+	}
+
+	request()
+	assert.Equal(t, 0.0, testutil.ToFloat64(TelegramErrors.WithLabelValues("sendMessage")))
+
+	statusCode = http.StatusTooManyRequests
+	request()
+	assert.Equal(t, 1.0, testutil.ToFloat64(TelegramErrors.WithLabelValues("sendMessage")))
+
+	err = errors.New("connection reset")
+	request()
+	assert.Equal(t, 2.0, testutil.ToFloat64(TelegramErrors.WithLabelValues("sendMessage")))
+}
@@ -0,0 +1,209 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// Telegram allows about 30 messages a second overall, one a second to a
+	// chat and 20 a minute to a group.
+	globalInterval = time.Second / 30
+	chatInterval   = time.Second
+	groupInterval  = 3 * time.Second
+
+	maxAttempts    = 5
+	initialBackoff = time.Second
+)
+
+// HTTPClient is the client interface the Telegram bot sends its requests with.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client retries the requests of the bot the Bot API turns down for flooding
+// or fails on. Keeping to the per chat limits is left to the callers, so a
+// busy chat doesn't hold up the others.
+type Client struct {
+	client HTTPClient
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func NewClient(client HTTPClient) *Client {
+	return &Client{
+		client: client,
+		sleep:  sleep,
+	}
+}
+
+// ForbiddenError is returned for requests the Bot API refused, usually
+// because the user blocked the bot.
+type ForbiddenError struct {
+	Description string
+}
+
+func (e *ForbiddenError) Error() string {
+	return "telegram: forbidden: " + e.Description
+}
+
+// apiResponse is the part of a Bot API response the client looks at.
+type apiResponse struct {
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.client.Do(req)
+
+		// Uploads stream their body and can't be sent again
+		retryable := attempt < maxAttempts && (req.Body == nil || req.GetBody != nil)
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			wait = backoff
+		case resp.StatusCode == http.StatusTooManyRequests:
+			wait = retryAfter(resp)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			wait = backoff
+		case resp.StatusCode == http.StatusForbidden:
+			return nil, &ForbiddenError{Description: readAPIResponse(resp).Description}
+		default:
+			return resp, err
+		}
+
+		if !retryable {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		slog.WarnContext(ctx, "Retrying Telegram request",
+			"status", statusCode(resp), "error", err, "attempt", attempt, "wait", wait)
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// retryAfter reads how long the Bot API asks to wait from a 429 response and
+// puts the body back for the caller.
+func retryAfter(resp *http.Response) time.Duration {
+	retryAfter := readAPIResponse(resp).Parameters.RetryAfter
+	if retryAfter <= 0 {
+		return initialBackoff
+	}
+
+	return time.Duration(retryAfter) * time.Second
+}
+
+// readAPIResponse decodes the body of the response and puts it back for the
+// caller.
+func readAPIResponse(resp *http.Response) apiResponse {
+	var apiResp apiResponse
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return apiResp
+	}
+
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return apiResponse{}
+	}
+	return apiResp
+}
+
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Limiter hands out send times so messages keep the minimum intervals, both
+// overall and per chat.
+type Limiter struct {
+	mu         sync.Mutex
+	globalNext time.Time
+	chatNext   map[int64]time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{chatNext: map[int64]time.Time{}}
+}
+
+// Reserve books the next send to the chat. When the chat got a message too
+// recently it books nothing and returns false with how long until the chat is
+// free, so the message can be put off. Otherwise it returns true with how
+// long to wait for the overall limit.
+func (l *Limiter) Reserve(chatID int64, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if next, ok := l.chatNext[chatID]; ok && next.After(now) {
+		return next.Sub(now), false
+	}
+
+	at := now
+	if l.globalNext.After(at) {
+		at = l.globalNext
+	}
+
+	interval := chatInterval
+	// Group and channel IDs are negative
+	if chatID < 0 {
+		interval = groupInterval
+	}
+
+	l.globalNext = at.Add(globalInterval)
+	l.chatNext[chatID] = at.Add(interval)
+
+	// Forget the chats whose slots passed, so the map doesn't keep growing
+	if len(l.chatNext) > 1000 {
+		for id, next := range l.chatNext {
+			if next.Before(now) {
+				delete(l.chatNext, id)
+			}
+		}
+	}
+
+	return at.Sub(now), true
+}
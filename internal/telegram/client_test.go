@@ -0,0 +1,132 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+// fakeBotAPI answers the requests with the given responses in turn and
+// records the bodies it got.
+type fakeBotAPI struct {
+	responses []fakeResponse
+	bodies    []string
+}
+
+func (f *fakeBotAPI) Do(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	f.bodies = append(f.bodies, string(body))
+
+	response := f.responses[0]
+	if len(f.responses) > 1 {
+		f.responses = f.responses[1:]
+	}
+
+	return &http.Response{
+		StatusCode: response.status,
+		Body:       io.NopCloser(strings.NewReader(response.body)),
+	}, nil
+}
+
+func newTestClient(api *fakeBotAPI) (*Client, *[]time.Duration) {
+	var waits []time.Duration
+
+	client := NewClient(api)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		if d > 0 {
+			waits = append(waits, d)
+		}
+		return nil
+	}
+
+	return client, &waits
+}
+
+func newSendMessageRequest(chatID string) *http.Request {
+	values := url.Values{"chat_id": {chatID}, "text": {"Hallo"}}
+	req, _ := http.NewRequest(http.MethodPost, "https://api.telegram.org/botTOKEN/sendMessage", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestClientRetriesFlooding(t *testing.T) {
+	api := &fakeBotAPI{responses: []fakeResponse{
+		{http.StatusTooManyRequests, `{"ok":false,"error_code":429,"parameters":{"retry_after":7}}`},
+		{http.StatusBadGateway, ``},
+		{http.StatusOK, `{"ok":true}`},
+	}}
+	client, waits := newTestClient(api)
+
+	resp, err := client.Do(newSendMessageRequest("42"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Flooding waits as long as asked, server errors back off
+	assert.Equal(t, 7*time.Second, (*waits)[0])
+	assert.Contains(t, *waits, 2*initialBackoff)
+	assert.Len(t, api.bodies, 3)
+	assert.Equal(t, api.bodies[0], api.bodies[2])
+}
+
+func TestClientGivesUp(t *testing.T) {
+	api := &fakeBotAPI{responses: []fakeResponse{{http.StatusInternalServerError, `{"ok":false}`}}}
+	client, _ := newTestClient(api)
+
+	resp, err := client.Do(newSendMessageRequest("42"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Len(t, api.bodies, maxAttempts)
+}
+
+func TestClientReturnsForbiddenErrors(t *testing.T) {
+	api := &fakeBotAPI{responses: []fakeResponse{
+		{http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`},
+	}}
+	client, _ := newTestClient(api)
+
+	_, err := client.Do(newSendMessageRequest("42"))
+	var forbidden *ForbiddenError
+	assert.ErrorAs(t, err, &forbidden)
+	assert.Equal(t, "Forbidden: bot was blocked by the user", forbidden.Description)
+	assert.Len(t, api.bodies, 1)
+}
+
+func TestLimiterReserve(t *testing.T) {
+	l := NewLimiter()
+	now := time.Now()
+
+	reserve := func(chatID int64) time.Duration {
+		wait, ok := l.Reserve(chatID, now)
+		assert.True(t, ok)
+		return wait
+	}
+
+	assert.Equal(t, time.Duration(0), reserve(1))
+	assert.Equal(t, globalInterval, reserve(2))
+
+	// Busy chats are put off instead of holding up the others
+	wait, ok := l.Reserve(1, now)
+	assert.False(t, ok)
+	assert.Equal(t, chatInterval, wait)
+	assert.Equal(t, 2*globalInterval, reserve(-100))
+
+	wait, ok = l.Reserve(-100, now.Add(time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 2*globalInterval+groupInterval-time.Second, wait)
+
+	// The chat is free again once its interval passed
+	wait, ok = l.Reserve(1, now.Add(chatInterval))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait)
+}
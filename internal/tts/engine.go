@@ -0,0 +1,81 @@
+package tts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Engine turns text into OGG/Opus voice notes by running a local
+// text-to-speech command, e.g. a small script wrapping espeak-ng or piper and
+// an opus encoder. The text is written to the command's stdin and the
+// {output} and {tag} placeholders in the command are replaced by the file to
+// write and the tag the phrase is filed under.
+type Engine struct {
+	Command  string
+	CacheDir string
+}
+
+func NewEngine(command string, cacheDir string) *Engine {
+	return &Engine{
+		Command:  command,
+		CacheDir: cacheDir,
+	}
+}
+
+// Synthesize returns the path of the voice note for the given text,
+// generating it only when it isn't cached yet.
+func (e *Engine) Synthesize(text string, tag string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("nothing to synthesize")
+	}
+
+	if err := os.MkdirAll(e.CacheDir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(e.CacheDir, e.cacheKey(text, tag)+".ogg")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	// Write to a temporary file of its own first, so a failed run never leaves
+	// a broken cache entry and concurrent runs don't write over each other
+	tmpFile, err := os.CreateTemp(e.CacheDir, "*.ogg")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	replacer := strings.NewReplacer("{output}", tmpPath, "{tag}", tag)
+	args := strings.Fields(e.Command)
+	for i, arg := range args {
+		args[i] = replacer.Replace(arg)
+	}
+	if len(args) == 0 {
+		return "", errors.New("no text-to-speech command configured")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", errors.New(err.Error() + ": " + strings.TrimSpace(string(output)))
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (e *Engine) cacheKey(text string, tag string) string {
+	hash := sha256.Sum256([]byte(e.Command + "\x00" + tag + "\x00" + text))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,61 @@
+package tts
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSynthesize(t *testing.T) {
+	engine := NewEngine("tee {output}", t.TempDir())
+
+	path, err := engine.Synthesize("Guten Morgen", "german")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "Guten Morgen", string(content))
+
+	// A cached voice note is reused
+	cachedPath, err := engine.Synthesize("Guten Morgen", "german")
+	assert.NoError(t, err)
+	assert.Equal(t, path, cachedPath)
+}
+
+func TestSynthesizeConcurrently(t *testing.T) {
+	engine := NewEngine("tee {output}", t.TempDir())
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			path, err := engine.Synthesize("Guten Morgen", "german")
+			assert.NoError(t, err)
+
+			content, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, "Guten Morgen", string(content))
+		}()
+	}
+	wg.Wait()
+
+	// Only the voice note is left behind
+	entries, err := os.ReadDir(engine.CacheDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestSynthesizeFailure(t *testing.T) {
+	engine := NewEngine("false {output}", t.TempDir())
+
+	_, err := engine.Synthesize("Guten Morgen", "german")
+	assert.Error(t, err)
+
+	entries, err := os.ReadDir(engine.CacheDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
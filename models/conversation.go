@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Conversation keeps track of what the bot is waiting for in a chat, so the
+// next message can be handled as part of an ongoing interaction instead of a
+// new phrase.
+type Conversation struct {
+	ID             uint              `gorm:"primaryKey"`
+	TelegramChatID int64             `gorm:"not null;uniqueIndex"`
+	UserID         uint              `gorm:"not null"`
+	State          ConversationState `gorm:"size:30;not null"`
+	SessionID      uint              `gorm:""`
+	PhraseID       uint              `gorm:""`
+	ExpiresAt      time.Time         `gorm:"not null"`
+	UpdatedAt      time.Time         `gorm:"autoUpdateTime"`
+}
+
+func (c *Conversation) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+type ConversationState string
+
+const (
+	// ConversationAwaitingAnswer waits for the typed answer to PhraseID.
+	ConversationAwaitingAnswer ConversationState = "awaiting_answer"
+	// ConversationAwaitingTagName waits for a tag to add to PhraseID.
+	ConversationAwaitingTagName ConversationState = "awaiting_tag_name"
+)
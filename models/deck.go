@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Deck shares the owner's phrases filed under one hashtag, or the phrases
+// posted in a Telegram group, with its members. Every member keeps their own
+// reviews of the shared phrases.
+type Deck struct {
+	ID             uint         `gorm:"primaryKey"`
+	OwnerID        uint         `gorm:"not null;uniqueIndex:idx_deck_owner_name"`
+	Name           string       `gorm:"not null;uniqueIndex:idx_deck_owner_name"`
+	InviteToken    string       `gorm:"not null;uniqueIndex;size:32"`
+	TelegramChatID *int64       `gorm:"uniqueIndex"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime"`
+	Owner          User         `gorm:"foreignKey:OwnerID"`
+	Members        []DeckMember `gorm:"foreignKey:DeckID"`
+}
+
+// IsGroupDeck reports whether the deck collects the phrases of a Telegram group.
+func (d *Deck) IsGroupDeck() bool {
+	return d.TelegramChatID != nil
+}
+
+type DeckMember struct {
+	ID       uint      `gorm:"primaryKey"`
+	DeckID   uint      `gorm:"not null;uniqueIndex:idx_deck_member"`
+	UserID   uint      `gorm:"not null;uniqueIndex:idx_deck_member;index"`
+	JoinedAt time.Time `gorm:"autoCreateTime"`
+}
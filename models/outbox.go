@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// OutboxMessage is a message to send to a user. It's written in the same
+// transaction as the change it comes from and sent afterwards, so nothing
+// gets lost when sending fails or the process stops in between.
+type OutboxMessage struct {
+	ID        uint         `gorm:"primaryKey"`
+	UserID    uint         `gorm:"not null;index"`
+	ChatID    int64        `gorm:"not null"`
+	Kind      OutboxKind   `gorm:"size:20;not null"`
+	SessionID uint         `gorm:"not null;default:0"`
+	PhraseID  uint         `gorm:"not null;default:0"`
+	Text      string       `gorm:"type:text"`
+	Status    OutboxStatus `gorm:"size:20;not null;default:pending;index"`
+	Attempts  uint         `gorm:"not null;default:0"`
+	LastError string       `gorm:"type:text"`
+	// SentMessageID is the Telegram message the outbox message went out as
+	SentMessageID int       `gorm:"not null;default:0"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	// AvailableAt puts the message off until then, e.g. while its chat is at
+	// its rate limit
+	AvailableAt *time.Time `gorm:""`
+	ClaimedAt   *time.Time `gorm:""`
+	SentAt      *time.Time `gorm:""`
+}
+
+type OutboxKind string
+
+const (
+	// OutboxKindPhrase sends the phrase for review in the session.
+	OutboxKindPhrase OutboxKind = "phrase"
+	// OutboxKindText sends the text.
+	OutboxKindText OutboxKind = "text"
+	// OutboxKindExpireKeyboards removes the keyboards of the phrases sent in
+	// the session, it's not bound to a chat.
+	OutboxKindExpireKeyboards OutboxKind = "expire_keyboards"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending"
+	// OutboxStatusSending messages were claimed by a dispatcher.
+	OutboxStatusSending OutboxStatus = "sending"
+	OutboxStatusSent    OutboxStatus = "sent"
+	// OutboxStatusFailed messages ran out of attempts.
+	OutboxStatusFailed OutboxStatus = "failed"
+)
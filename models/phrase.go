@@ -1,14 +1,43 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Phrase struct {
-	ID                uint      `gorm:"primaryKey"`
-	UserID            uint      `gorm:"not null;index"`
-	TelegramMessageID int       `gorm:"not null;uniqueIndex"`
-	Text              string    `gorm:"not null"`
-	IsMastered        bool      `gorm:"not null;default:false"`
-	CreatedAt         time.Time `gorm:"autoCreateTime"`
-	User              User      `gorm:"foreignKey:UserID"`
-	Tags              []Tag     `gorm:"many2many:phrase_tag"`
+	ID     uint  `gorm:"primaryKey"`
+	UserID uint  `gorm:"not null;index"`
+	DeckID *uint `gorm:"index"`
+	// Telegram message IDs are only unique within a chat
+	TelegramChatID    int64          `gorm:"not null;default:0;uniqueIndex:idx_phrase_chat_message"`
+	TelegramMessageID int            `gorm:"not null;uniqueIndex:idx_phrase_chat_message"`
+	Text              string         `gorm:"not null"`
+	MediaType         MediaType      `gorm:"size:20"`
+	FileID            string         `gorm:"size:255"`
+	IsMastered        bool           `gorm:"not null;default:false"`
+	IsSuspended       bool           `gorm:"not null;default:false"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime"`
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+	User              User           `gorm:"foreignKey:UserID"`
+	Tags              []Tag          `gorm:"many2many:phrase_tag"`
+}
+
+// MediaType tells which kind of Telegram file, if any, a phrase carries
+// along with its text.
+type MediaType string
+
+const (
+	MediaTypeNone    MediaType = ""
+	MediaTypeVoice   MediaType = "voice"
+	MediaTypeAudio   MediaType = "audio"
+	MediaTypePhoto   MediaType = "photo"
+	MediaTypeSticker MediaType = "sticker"
+)
+
+// HidesAnswer reports whether the phrase text is kept back during review
+// until the user asks for it, as visual phrases are meant to be recalled.
+func (t MediaType) HidesAnswer() bool {
+	return t == MediaTypePhoto || t == MediaTypeSticker
 }
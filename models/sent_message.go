@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SentMessage is a phrase the bot sent for review, so its keyboard can be
+// taken away once answering it no longer makes sense.
+type SentMessage struct {
+	ID        uint  `gorm:"primaryKey"`
+	UserID    uint  `gorm:"not null;index"`
+	ChatID    int64 `gorm:"not null;uniqueIndex:idx_sent_message"`
+	MessageID int   `gorm:"not null;uniqueIndex:idx_sent_message"`
+	SessionID uint  `gorm:"not null;index"`
+	PhraseID  uint  `gorm:"not null;index"`
+	// HasKeyboard is set while the message can still be answered with its
+	// inline keyboard
+	HasKeyboard bool      `gorm:"not null;default:false"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
@@ -3,8 +3,34 @@ package models
 import "time"
 
 type Session struct {
-	ID        uint       `gorm:"primaryKey"`
-	UserID    uint       `gorm:"not null"`
-	StartedAt time.Time  `gorm:"autoCreateTime"`
-	EndedAt   *time.Time `gorm:""`
+	ID             uint             `gorm:"primaryKey"`
+	UserID         uint             `gorm:"not null"`
+	StartedAt      time.Time        `gorm:"autoCreateTime"`
+	LastActivityAt *time.Time       `gorm:""`
+	EndedAt        *time.Time       `gorm:""`
+	EndReason      SessionEndReason `gorm:"size:20"`
+	ReviewedCount  uint             `gorm:"not null;default:0"`
+	AverageQuality float64          `gorm:"not null;default:0"`
+}
+
+// LastActiveAt returns the last time the session saw any activity.
+func (s *Session) LastActiveAt() time.Time {
+	if s.LastActivityAt != nil {
+		return *s.LastActivityAt
+	}
+	return s.StartedAt
+}
+
+type SessionEndReason string
+
+const (
+	SessionEndCompleted SessionEndReason = "completed"
+	SessionEndExhausted SessionEndReason = "exhausted"
+	SessionEndTimeout   SessionEndReason = "timeout"
+	SessionEndForced    SessionEndReason = "forced"
+)
+
+type SessionStats struct {
+	ReviewedCount  uint
+	AverageQuality float64
 }
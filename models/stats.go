@@ -0,0 +1,11 @@
+package models
+
+// Stats sums up the usage of the whole bot.
+type Stats struct {
+	Users          int64
+	BlockedUsers   int64
+	Phrases        int64
+	Reviews        int64
+	ReviewsToday   int64
+	ActiveSessions int64
+}
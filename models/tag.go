@@ -4,3 +4,12 @@ type Tag struct {
 	ID   uint   `gorm:"primaryKey"`
 	Name string `gorm:"not null;unique"`
 }
+
+// UserTagSetting holds a user's own settings for the phrases filed under a
+// tag, tags themselves are shared by all users.
+type UserTagSetting struct {
+	ID         uint `gorm:"primaryKey"`
+	UserID     uint `gorm:"not null;uniqueIndex:idx_user_tag_setting"`
+	TagID      uint `gorm:"not null;uniqueIndex:idx_user_tag_setting"`
+	TTSEnabled bool `gorm:"not null;default:false"`
+}
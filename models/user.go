@@ -3,12 +3,61 @@ package models
 import "time"
 
 type User struct {
-	ID             uint      `gorm:"primaryKey"`
-	TelegramChatID int64     `gorm:"unique;not null"`
-	FirstName      string    `gorm:"size:100"`
-	LastName       string    `gorm:"size:100"`
-	Username       string    `gorm:"size:100"`
-	LanguageCode   string    `gorm:"size:10"`
-	IsBot          bool      `gorm:"not null"`
-	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	ID             uint       `gorm:"primaryKey"`
+	TelegramChatID int64      `gorm:"unique;not null"`
+	FirstName      string     `gorm:"size:100"`
+	LastName       string     `gorm:"size:100"`
+	Username       string     `gorm:"size:100"`
+	LanguageCode   string     `gorm:"size:10"`
+	Language       string     `gorm:"size:10"`
+	IsBot          bool       `gorm:"not null"`
+	TTSEnabled     bool       `gorm:"not null;default:false"`
+	ReviewMode     ReviewMode `gorm:"size:20;not null;default:rate"`
+	IsBlocked      bool       `gorm:"not null;default:false"`
+	Status         UserStatus `gorm:"size:20;not null;default:active"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime"`
+}
+
+// UserStatus tells whether the user was let in to use the bot.
+type UserStatus string
+
+const (
+	UserStatusActive UserStatus = "active"
+	// UserStatusPending users wait for an admin to approve them.
+	UserStatusPending UserStatus = "pending"
+	// UserStatusRejected users were turned down by an admin.
+	UserStatusRejected UserStatus = "rejected"
+	// UserStatusInactive users blocked the bot, so nothing is sent to them
+	// until they write to it again.
+	UserStatusInactive UserStatus = "inactive"
+)
+
+// CanReview tells whether the user gets phrases to review.
+func (u *User) CanReview() bool {
+	return u.Status == UserStatusActive && !u.IsBlocked
+}
+
+// PreferredLanguage is the language code the bot talks to the user in, the
+// one they picked with /language or else the one of their Telegram app.
+func (u *User) PreferredLanguage() string {
+	if u.Language != "" {
+		return u.Language
+	}
+	return u.LanguageCode
+}
+
+// ReviewMode tells how phrases are put to the user during review.
+type ReviewMode string
+
+const (
+	// ReviewModeRate shows the phrase and lets the user rate their recall.
+	ReviewModeRate ReviewMode = "rate"
+	// ReviewModeQuiz asks for the back of two-sided phrases with multiple choices.
+	ReviewModeQuiz ReviewMode = "quiz"
+	// ReviewModeTyped asks the user to type the back of two-sided phrases.
+	ReviewModeTyped ReviewMode = "typed"
+)
+
+func (m ReviewMode) IsValid() bool {
+	return m == ReviewModeRate || m == ReviewModeQuiz || m == ReviewModeTyped
 }